@@ -0,0 +1,169 @@
+package tbln
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// ChainPrevHashKey is the extra name that stores the hash of the previous segment.
+// The value is written as | hashType | hash |.
+const ChainPrevHashKey = "prev_hash"
+
+// ChainWriter appends hash-chained segments to an append-only TBLN log.
+// Each segment is a block separated by a blank line,
+// and carries the hash of the previous segment in the extra.
+type ChainWriter struct {
+	w        *Writer
+	hashType string
+	last     *TBLN
+}
+
+// NewChainWriter returns a new ChainWriter that writes to w.
+// last is the last segment of the existing log (see VerifyChain),
+// or nil to start a new log.
+func NewChainWriter(w io.Writer, hashType string, last *TBLN) *ChainWriter {
+	return &ChainWriter{
+		w:        NewWriter(w),
+		hashType: hashType,
+		last:     last,
+	}
+}
+
+// NewSegment returns a new segment chained to the last segment.
+// TableName, names, types and primarykey are inherited from the last segment.
+func (cw *ChainWriter) NewSegment() (*TBLN, error) {
+	seg := NewTBLN()
+	if cw.last == nil {
+		return seg, nil
+	}
+	prev, ok := cw.last.Hashes[cw.hashType]
+	if !ok {
+		return nil, fmt.Errorf("no %s hash in the last segment", cw.hashType)
+	}
+	if name := cw.last.TableName(); name != "" {
+		seg.SetTableName(name)
+	}
+	if err := seg.SetNames(cw.last.Names()); err != nil {
+		return nil, err
+	}
+	if err := seg.SetTypes(cw.last.Types()); err != nil {
+		return nil, err
+	}
+	if pk, ok := cw.last.Extras["primarykey"]; ok {
+		seg.Extras["primarykey"] = pk
+	}
+	seg.Extras[ChainPrevHashKey] = NewExtra(JoinRow([]string{cw.hashType, hex.EncodeToString(prev)}), true)
+	return seg, nil
+}
+
+// Seal makes all extras targets of the hash and calculates the hash of the segment.
+// Sign the segment after Seal for incremental signing.
+func (cw *ChainWriter) Seal(seg *TBLN) error {
+	seg.AllTargetHash(true)
+	return seg.SumHash(cw.hashType)
+}
+
+// Append writes the segment to w.
+// If the segment has not been sealed, Append seals it.
+func (cw *ChainWriter) Append(seg *TBLN) error {
+	if err := checkPrevHash(seg, cw.last, cw.hashType); err != nil {
+		return err
+	}
+	if _, ok := seg.Hashes[cw.hashType]; !ok {
+		if err := cw.Seal(seg); err != nil {
+			return err
+		}
+	}
+	if !seg.Verify() {
+		return fmt.Errorf("segment hash is not up to date")
+	}
+	if cw.last != nil {
+		if _, err := io.WriteString(cw.w.Writer, "\n"); err != nil {
+			return err
+		}
+	}
+	if err := WriteAll(cw.w.Writer, seg); err != nil {
+		return err
+	}
+	cw.last = seg
+	return nil
+}
+
+// VerifyChain reads all segments from r and verifies the hash of each segment
+// and the link to the previous segment.
+// It returns the last segment, which can be passed to NewChainWriter.
+func VerifyChain(r io.Reader, hashType string) (*TBLN, error) {
+	return walkChain(r, hashType, nil)
+}
+
+// VerifyChainPolicy is VerifyChain that also verifies that
+// the signatures of each segment satisfy the policy.
+func VerifyChainPolicy(r io.Reader, hashType string, policy Policy, keys KeyStore) (*TBLN, error) {
+	return walkChain(r, hashType, func(seg *TBLN) error {
+		_, err := seg.VerifyPolicy(policy, keys)
+		return err
+	})
+}
+
+func walkChain(r io.Reader, hashType string, verify func(*TBLN) error) (*TBLN, error) {
+	tr := NewReader(r)
+	var last *TBLN
+	for n := 1; ; n++ {
+		seg, err := tr.ReadBlock()
+		if err != nil {
+			if err == io.EOF {
+				if last == nil {
+					return nil, fmt.Errorf("no segment")
+				}
+				return last, nil
+			}
+			return nil, fmt.Errorf("segment %d: %w", n, err)
+		}
+		if _, ok := seg.Hashes[hashType]; !ok {
+			return nil, fmt.Errorf("segment %d: no %s hash", n, hashType)
+		}
+		if !seg.Verify() {
+			return nil, fmt.Errorf("segment %d: hash verification failed", n)
+		}
+		if err := checkPrevHash(seg, last, hashType); err != nil {
+			return nil, fmt.Errorf("segment %d: %w", n, err)
+		}
+		if verify != nil {
+			if err := verify(seg); err != nil {
+				return nil, fmt.Errorf("segment %d: %w", n, err)
+			}
+		}
+		last = seg
+	}
+}
+
+// checkPrevHash checks that the segment is chained to prev.
+func checkPrevHash(seg *TBLN, prev *TBLN, hashType string) error {
+	ext, ok := seg.Extras[ChainPrevHashKey]
+	if prev == nil {
+		if ok {
+			return fmt.Errorf("first segment has %s", ChainPrevHashKey)
+		}
+		return nil
+	}
+	if !ok {
+		return fmt.Errorf("no %s", ChainPrevHashKey)
+	}
+	if !ext.hashTarget {
+		return fmt.Errorf("%s is not a target of the hash", ChainPrevHashKey)
+	}
+	v := SplitRow(fmt.Sprintf("%s", ext.Value()))
+	if len(v) != 2 || v[0] != hashType {
+		return fmt.Errorf("not analyze %s", ChainPrevHashKey)
+	}
+	h, err := hex.DecodeString(v[1])
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(h, prev.Hashes[hashType]) {
+		return fmt.Errorf("%s does not match the previous segment", ChainPrevHashKey)
+	}
+	return nil
+}
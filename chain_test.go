@@ -0,0 +1,111 @@
+package tbln
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+func chainHelper(t *testing.T, pkey ed25519.PrivateKey) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	cw := NewChainWriter(&buf, SHA256, nil)
+	rows := [][][]string{
+		{{"1", "login"}, {"2", "logout"}},
+		{{"3", "login"}},
+		{{"4", "update"}, {"5", "logout"}},
+	}
+	for i, segRows := range rows {
+		seg, err := cw.NewSegment()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			seg.SetTableName("audit")
+			if err := seg.SetNames([]string{"id", "event"}); err != nil {
+				t.Fatal(err)
+			}
+			if err := seg.SetTypes([]string{"int", "text"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, row := range segRows {
+			if err := seg.AddRows(row); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if pkey != nil {
+			if err := cw.Seal(seg); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := seg.Sign("test", pkey); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := cw.Append(seg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &buf
+}
+
+func TestVerifyChain(t *testing.T) {
+	buf := chainHelper(t, nil)
+	log := buf.String()
+	last, err := VerifyChain(strings.NewReader(log), SHA256)
+	if err != nil {
+		t.Fatalf("VerifyChain() error = %v", err)
+	}
+	if last.RowNum != 2 || last.TableName() != "audit" {
+		t.Errorf("VerifyChain() last = %v", last)
+	}
+
+	// Append to the existing log.
+	cw := NewChainWriter(buf, SHA256, last)
+	seg, err := cw.NewSegment()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := seg.AddRows([]string{"6", "login"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.Append(seg); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyChain(bytes.NewReader(buf.Bytes()), SHA256); err != nil {
+		t.Errorf("VerifyChain() appended error = %v", err)
+	}
+
+	blocks := strings.Split(log, "\n\n")
+	tests := []struct {
+		name string
+		log  string
+	}{
+		{name: "tampered", log: strings.Replace(log, "| 3 | login |", "| 3 | logout |", 1)},
+		{name: "removed", log: blocks[0] + "\n\n" + blocks[2]},
+		{name: "swapped", log: blocks[1] + "\n\n" + blocks[0]},
+		{name: "empty", log: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := VerifyChain(strings.NewReader(tt.log), SHA256); err == nil {
+				t.Errorf("VerifyChain() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestVerifyChainPolicy(t *testing.T) {
+	pkey := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{1}, ed25519.SeedSize))
+	keys := KeyStore{"test": pkey.Public().(ed25519.PublicKey)}
+	buf := chainHelper(t, pkey)
+	if _, err := VerifyChainPolicy(bytes.NewReader(buf.Bytes()), SHA256, Policy{Mode: RequireAll}, keys); err != nil {
+		t.Errorf("VerifyChainPolicy() error = %v", err)
+	}
+	unsigned := chainHelper(t, nil)
+	if _, err := VerifyChainPolicy(unsigned, SHA256, Policy{Mode: RequireAll}, keys); err == nil {
+		t.Errorf("VerifyChainPolicy() unsigned error = nil, want error")
+	}
+}
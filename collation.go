@@ -0,0 +1,101 @@
+package tbln
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// CollationKey is the extra name that stores the collation of text columns.
+// The value is "byte", "nocase" or a BCP 47 language tag such as "ja" or "und".
+const CollationKey = "collation"
+
+// Collation names.
+const (
+	CollationByte   = "byte"
+	CollationNoCase = "nocase"
+)
+
+// collation compares two text values.
+type collation func(a, b string) int
+
+// newCollation returns the collation of the name.
+// It returns nil for the byte order.
+func newCollation(name string) (collation, error) {
+	switch name {
+	case "", CollationByte:
+		return nil, nil
+	case CollationNoCase:
+		return func(a, b string) int {
+			return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+		}, nil
+	}
+	tag, err := language.Parse(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid collation: %s", name)
+	}
+	return collate.New(tag).CompareString, nil
+}
+
+// Collation returns the collation of the Definition.
+func (d *Definition) Collation() string {
+	if d.ExtraValue(CollationKey) == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s", d.ExtraValue(CollationKey))
+}
+
+// SetCollation sets the collation of text columns.
+// The rows must be sorted by the collation.
+func (d *Definition) SetCollation(name string) error {
+	if _, err := newCollation(name); err != nil {
+		return err
+	}
+	d.SetExtra(CollationKey, name)
+	return nil
+}
+
+// commonCollation returns the collation of t1d or t2d.
+func commonCollation(t1d, t2d *Definition) (string, error) {
+	c1, c2 := t1d.Collation(), t2d.Collation()
+	if c1 == "" {
+		return c2, nil
+	}
+	if c2 != "" && c1 != c2 {
+		return "", fmt.Errorf("different collation: %s:%s", c1, c2)
+	}
+	return c1, nil
+}
+
+// isTextType returns true if the type is compared as text.
+func isTextType(dType string) bool {
+	switch dType {
+	case "", "text", "varchar", "character varying", "char", "character", "bpchar", "name":
+		return true
+	}
+	return false
+}
+
+// compareKey compares the keys of two rows with the collation.
+// Keys that the collation treats as equal are compared by bytes.
+func compareKey(pKeys []Pkey, coll collation, row1 []string, row2 []string) int {
+	for _, pk := range pKeys {
+		var ret int
+		if coll != nil && isTextType(pk.Typ) {
+			ret = coll(row1[pk.Pos], row2[pk.Pos])
+			if ret == 0 {
+				// Keys equal under the collation are ordered by bytes
+				// so that they remain distinct keys.
+				ret = strings.Compare(row1[pk.Pos], row2[pk.Pos])
+			}
+		} else {
+			ret = compareType(pk.Typ, row1[pk.Pos], row2[pk.Pos])
+		}
+		if ret != 0 {
+			return ret
+		}
+	}
+	return 0
+}
@@ -0,0 +1,161 @@
+package tbln
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+var TestCollation1 = `; name: | name | age |
+; type: | text | int |
+; primarykey: | name |
+; collation: und
+| apple | 1 |
+| Émile | 2 |
+| fig | 3 |
+`
+
+var TestCollation2 = `; name: | name | age |
+; type: | text | int |
+; primarykey: | name |
+; collation: und
+| Émile | 2 |
+| fig | 4 |
+| grape | 5 |
+`
+
+func Test_newCollation(t *testing.T) {
+	tests := []struct {
+		name    string
+		a       string
+		b       string
+		want    int
+		wantErr bool
+	}{
+		{name: "", a: "f", b: "é", want: -1},
+		{name: "byte", a: "B", b: "a", want: -1},
+		{name: "nocase", a: "B", b: "a", want: 1},
+		{name: "nocase", a: "Bob", b: "bob", want: -1},
+		{name: "nocase", a: "bob", b: "bob", want: 0},
+		{name: "und", a: "f", b: "é", want: 1},
+		{name: "ja", a: "か", b: "あ", want: 1},
+		{name: "invalid-collation-name", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coll, err := newCollation(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newCollation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			pk := []Pkey{{Pos: 0, Typ: "text"}}
+			if got := compareKey(pk, coll, []string{tt.a}, []string{tt.b}); got != tt.want {
+				t.Errorf("compareKey(%s, %s) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompare_Collation(t *testing.T) {
+	var buf bytes.Buffer
+	err := DiffAll(&buf, NewReader(bytes.NewBufferString(TestCollation1)), NewReader(bytes.NewBufferString(TestCollation2)), OnlyDiff)
+	if err != nil {
+		t.Fatalf("DiffAll() error = %v", err)
+	}
+	want := `-| apple | 1 |
+-| fig | 3 |
++| fig | 4 |
++| grape | 5 |
+`
+	if got := buf.String(); got != want {
+		t.Errorf("DiffAll() = \n%v, want \n%v", got, want)
+	}
+
+	cmp, err := NewCompare(NewReader(bytes.NewBufferString(TestCollation1)), NewReader(bytes.NewBufferString(TestCollation2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmp.Collation != "und" {
+		t.Errorf("Compare.Collation = %v, want und", cmp.Collation)
+	}
+	if err := cmp.SetCollation(CollationByte); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cmp.DiffStats(0); err == nil {
+		t.Errorf("Compare.DiffStats() byte order error = nil, want error")
+	}
+
+	other := `; name: | name | age |
+; type: | text | int |
+; primarykey: | name |
+; collation: nocase
+| apple | 1 |
+`
+	if _, err := NewCompare(NewReader(bytes.NewBufferString(TestCollation1)), NewReader(bytes.NewBufferString(other))); err == nil {
+		t.Errorf("NewCompare() different collation error = nil, want error")
+	}
+}
+
+func TestCompare_CollationTie(t *testing.T) {
+	src1 := `; name: | name | age |
+; type: | text | int |
+; primarykey: | name |
+; collation: nocase
+| A | 1 |
+| a | 2 |
+`
+	src2 := `; name: | name | age |
+; type: | text | int |
+; primarykey: | name |
+; collation: nocase
+| a | 2 |
+`
+	cmp, err := NewCompare(NewReader(bytes.NewBufferString(src1)), NewReader(bytes.NewBufferString(src2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*DiffRow{
+		{Les: -1, Self: []string{"A", "1"}},
+		{Les: 0, Self: []string{"a", "2"}, Other: []string{"a", "2"}},
+	}
+	for _, w := range want {
+		dd, err := cmp.ReadDiffRow()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dd.Les != w.Les || !reflect.DeepEqual(dd.Self, w.Self) || !reflect.DeepEqual(dd.Other, w.Other) {
+			t.Errorf("Compare.ReadDiffRow() = %v, want %v", dd, w)
+		}
+	}
+}
+
+func TestSortReader_Collation(t *testing.T) {
+	src := `; name: | name |
+; type: | text |
+| fig |
+| Émile |
+| apple |
+`
+	sr := NewSortReader(NewReader(bytes.NewBufferString(src)))
+	sr.Collation = "und"
+	got := readRowsHelper(t, sr)
+	want := [][]string{{"apple"}, {"Émile"}, {"fig"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SortReader.ReadRow() = %v, want %v", got, want)
+	}
+}
+
+func TestDefinition_SetCollation(t *testing.T) {
+	d := NewDefinition()
+	if err := d.SetCollation("ja"); err != nil {
+		t.Fatal(err)
+	}
+	if d.Collation() != "ja" {
+		t.Errorf("Definition.Collation() = %v, want ja", d.Collation())
+	}
+	if err := d.SetCollation("invalid-collation-name"); err == nil {
+		t.Errorf("Definition.SetCollation() error = nil, want error")
+	}
+}
@@ -0,0 +1,64 @@
+package tbln
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Comparator returns true if two values are equal.
+type Comparator func(v1, v2 string) bool
+
+// AbsTolerance returns a Comparator that treats numbers as equal
+// if the difference is within tol.
+// Values that are not numbers are compared as strings.
+func AbsTolerance(tol float64) Comparator {
+	return func(v1, v2 string) bool {
+		f1, err1 := strconv.ParseFloat(v1, 64)
+		f2, err2 := strconv.ParseFloat(v2, 64)
+		if err1 != nil || err2 != nil {
+			return v1 == v2
+		}
+		return math.Abs(f1-f2) <= tol
+	}
+}
+
+// RelTolerance returns a Comparator that treats numbers as equal
+// if the difference is within tol relative to the larger absolute value.
+// Values that are not numbers are compared as strings.
+func RelTolerance(tol float64) Comparator {
+	return func(v1, v2 string) bool {
+		f1, err1 := strconv.ParseFloat(v1, 64)
+		f2, err2 := strconv.ParseFloat(v2, 64)
+		if err1 != nil || err2 != nil {
+			return v1 == v2
+		}
+		if f1 == f2 {
+			return true
+		}
+		return math.Abs(f1-f2) <= tol*math.Max(math.Abs(f1), math.Abs(f2))
+	}
+}
+
+// DecimalEqual is a Comparator that compares decimal numbers exactly,
+// so "1.0" and "1" are equal.
+// Values that are not numbers are compared as strings.
+func DecimalEqual(v1, v2 string) bool {
+	r1, ok1 := new(big.Rat).SetString(v1)
+	r2, ok2 := new(big.Rat).SetString(v2)
+	if !ok1 || !ok2 {
+		return v1 == v2
+	}
+	return r1.Cmp(r2) == 0
+}
+
+// EqualFold is a Comparator that ignores case.
+func EqualFold(v1, v2 string) bool {
+	return strings.EqualFold(v1, v2)
+}
+
+// TrimSpaceEqual is a Comparator that ignores leading and trailing white space.
+func TrimSpaceEqual(v1, v2 string) bool {
+	return strings.TrimSpace(v1) == strings.TrimSpace(v2)
+}
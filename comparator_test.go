@@ -0,0 +1,134 @@
+package tbln
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestComparator(t *testing.T) {
+	tests := []struct {
+		name string
+		c    Comparator
+		v1   string
+		v2   string
+		want bool
+	}{
+		{name: "abs", c: AbsTolerance(0.001), v1: "0.3", v2: "0.30000000000000004", want: true},
+		{name: "absOver", c: AbsTolerance(0.001), v1: "0.3", v2: "0.302", want: false},
+		{name: "absText", c: AbsTolerance(0.001), v1: "a", v2: "a", want: true},
+		{name: "rel", c: RelTolerance(0.01), v1: "1000", v2: "1005", want: true},
+		{name: "relOver", c: RelTolerance(0.01), v1: "1", v2: "1.05", want: false},
+		{name: "relZero", c: RelTolerance(0.01), v1: "0", v2: "0.0", want: true},
+		{name: "decimal", c: DecimalEqual, v1: "1.0", v2: "1", want: true},
+		{name: "decimalDiff", c: DecimalEqual, v1: "1.01", v2: "1", want: false},
+		{name: "decimalText", c: DecimalEqual, v1: "a", v2: "A", want: false},
+		{name: "fold", c: EqualFold, v1: "Bob", v2: "BOB", want: true},
+		{name: "trim", c: TrimSpaceEqual, v1: "Bob ", v2: "Bob", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.c(tt.v1, tt.v2); got != tt.want {
+				t.Errorf("Comparator(%q, %q) = %v, want %v", tt.v1, tt.v2, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompare_SetComparator(t *testing.T) {
+	t1 := `; name: | id | name | price |
+; type: | int | text | numeric |
+; primarykey: | id |
+| 1 | Bob | 1.0 |
+| 2 | Alice | 0.3 |
+`
+	t2 := `; name: | id | name | price |
+; type: | int | text | numeric |
+; primarykey: | id |
+| 1 | bob | 1 |
+| 2 | Alice | 0.31 |
+`
+	cmp, err := NewCompare(NewReader(bytes.NewBufferString(t1)), NewReader(bytes.NewBufferString(t2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmp.SetComparator("name", EqualFold); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmp.SetComparator("price", DecimalEqual); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmp.SetComparator("none", EqualFold); err == nil {
+		t.Errorf("Compare.SetComparator() no column error = nil, want error")
+	}
+	want := []int{0, 2}
+	for _, les := range want {
+		dd, err := cmp.ReadDiffRow()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dd.Les != les {
+			t.Errorf("Compare.ReadDiffRow() = %v, want Les %d", dd, les)
+		}
+		if les == 2 && !reflect.DeepEqual(dd.Changed, []int{2}) {
+			t.Errorf("Compare.ReadDiffRow() Changed = %v, want [2]", dd.Changed)
+		}
+	}
+	if _, err := cmp.ReadDiffRow(); err != io.EOF {
+		t.Errorf("Compare.ReadDiffRow() error = %v, want EOF", err)
+	}
+
+	cmp, err = NewCompare(NewReader(bytes.NewBufferString(t1)), NewReader(bytes.NewBufferString(t2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A user-supplied function.
+	prefix := func(v1, v2 string) bool { return strings.HasPrefix(v1, v2) || strings.HasPrefix(v2, v1) }
+	if err := cmp.SetComparator("price", prefix); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmp.SetIgnoreColumns("name"); err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		dd, err := cmp.ReadDiffRow()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dd.Les != 0 {
+			t.Errorf("Compare.ReadDiffRow() = %v, want Les 0", dd)
+		}
+	}
+}
+
+func TestCompare_SetComparatorMerge(t *testing.T) {
+	t1 := `; name: | id | price |
+; type: | int | numeric |
+; primarykey: | id |
+| 1 | 1.0 |
+| 2 | 2.5 |
+`
+	t2 := `; name: | id | price |
+; type: | int | numeric |
+; primarykey: | id |
+| 1 | 1 |
+| 2 | 2.50 |
+`
+	cmp, err := NewCompare(NewReader(bytes.NewBufferString(t1)), NewReader(bytes.NewBufferString(t2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmp.SetComparator("price", DecimalEqual); err != nil {
+		t.Fatal(err)
+	}
+	tb, err := cmp.MergeAll(MergeUpdate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"1", "1.0"}, {"2", "2.5"}}
+	if !reflect.DeepEqual(tb.Rows, want) {
+		t.Errorf("Compare.MergeAll() = %v, want %v", tb.Rows, want)
+	}
+}
@@ -3,8 +3,12 @@ package tbln
 import (
 	"fmt"
 	"io"
+	"math"
+	"math/big"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Compare represents a structure for comparing two TBLN.
@@ -15,8 +19,24 @@ type Compare struct {
 	t2Row  []string
 	t1Next bool
 	t2Next bool
+	ignore []int
+	equal  map[int]Comparator
+	coll   collation
+	t1Pos  []int
+	t2Pos  []int
+	// t1Reuse and t2Reuse are true if the rows must be copied to be kept.
+	t1Reuse bool
+	t2Reuse bool
 
 	PK []Pkey
+	// Collation is the collation of the text primary keys.
+	Collation string
+	// Columns is the names of the compared columns.
+	Columns []string
+	// AddedColumns is the names of the columns only in t2.
+	AddedColumns []string
+	// RemovedColumns is the names of the columns only in t1.
+	RemovedColumns []string
 }
 
 // Pkey represents the primary key.
@@ -27,13 +47,25 @@ type Pkey struct {
 }
 
 // DiffRow represents the difference between two rows.
+// Changed is the positions of the changed columns when Les is 2.
 type DiffRow struct {
-	Les   int
-	Self  []string
-	Other []string
+	Les     int
+	Self    []string
+	Other   []string
+	Changed []int
+}
+
+// ColumnChange represents the change of one column.
+type ColumnChange struct {
+	Pos  int
+	Name string
+	Old  string
+	New  string
 }
 
 // NewCompare returns a Reader interface.
+// If the columns of t1 and t2 are different, the columns are aligned
+// by name and only the columns in both are compared in the order of t1.
 func NewCompare(t1, t2 Reader) (*Compare, error) {
 	cmp := &Compare{
 		t1:     t1,
@@ -54,32 +86,53 @@ func NewCompare(t1, t2 Reader) (*Compare, error) {
 			return nil, err
 		}
 	}
+	if err := cmp.setColumns(); err != nil {
+		return nil, err
+	}
+	cmp.t1Reuse, cmp.t2Reuse = reusesRecord(t1), reusesRecord(t2)
+	cmp.t1Row = keepProjectRow(cmp.t1Pos, cmp.t1Row, cmp.t1Reuse)
+	cmp.t2Row = keepProjectRow(cmp.t2Pos, cmp.t2Row, cmp.t2Reuse)
 	cmp.PK, err = cmp.getPK()
 	if err != nil {
 		return nil, err
 	}
+	name, err := commonCollation(t1.GetDefinition(), t2.GetDefinition())
+	if err != nil {
+		return nil, err
+	}
+	if err := cmp.SetCollation(name); err != nil {
+		return nil, err
+	}
 	return cmp, nil
 }
 
+// Close closes t1 and t2 if they implement io.Closer,
+// such as the SortReaders of NewCompareUnsorted.
+func (cmp *Compare) Close() error {
+	var err error
+	for _, t := range []Reader{cmp.t1, cmp.t2} {
+		if c, ok := t.(io.Closer); ok {
+			if cerr := c.Close(); cerr != nil && err == nil {
+				err = cerr
+			}
+		}
+	}
+	return err
+}
+
 // ReadDiffRow compares two rows and returns the difference.
 func (cmp *Compare) ReadDiffRow() (*DiffRow, error) {
 	var err error
 	if cmp.t1Next {
-		cmp.t1Row, err = cmp.t1.ReadRow()
-		// Ignore errors to continue reading both ends.
+		cmp.t1Row, err = cmp.readNext(cmp.t1, cmp.t1Pos, cmp.t1Row, cmp.t1Reuse)
 		if err != nil {
-			if err != io.EOF {
-				return nil, err
-			}
+			return nil, err
 		}
 	}
 	if cmp.t2Next {
-		cmp.t2Row, err = cmp.t2.ReadRow()
-		// Ignore errors to continue reading both ends.
+		cmp.t2Row, err = cmp.readNext(cmp.t2, cmp.t2Pos, cmp.t2Row, cmp.t2Reuse)
 		if err != nil {
-			if err != io.EOF {
-				return nil, err
-			}
+			return nil, err
 		}
 	}
 
@@ -87,26 +140,199 @@ func (cmp *Compare) ReadDiffRow() (*DiffRow, error) {
 	case 0:
 		cmp.t1Next = true
 		cmp.t2Next = true
-		if JoinRow(cmp.t1Row) == JoinRow(cmp.t2Row) {
-			return &DiffRow{0, cmp.t1Row, cmp.t2Row}, nil
+		changed := cmp.changedColumns(cmp.t1Row, cmp.t2Row)
+		if len(changed) == 0 {
+			return &DiffRow{Les: 0, Self: cmp.t1Row, Other: cmp.t2Row}, nil
 		}
-		return &DiffRow{2, cmp.t1Row, cmp.t2Row}, nil
+		return &DiffRow{Les: 2, Self: cmp.t1Row, Other: cmp.t2Row, Changed: changed}, nil
 	case 1:
 		cmp.t1Next = false
 		cmp.t2Next = true
 		if len(cmp.t2Row) > 0 {
-			return &DiffRow{1, nil, cmp.t2Row}, nil
+			return &DiffRow{Les: 1, Self: nil, Other: cmp.t2Row}, nil
 		}
 	case -1:
 		cmp.t1Next = true
 		cmp.t2Next = false
 		if len(cmp.t1Row) > 0 {
-			return &DiffRow{-1, cmp.t1Row, nil}, nil
+			return &DiffRow{Les: -1, Self: cmp.t1Row, Other: nil}, nil
 		}
 	}
 	return nil, io.EOF
 }
 
+// SetIgnoreColumns sets the columns that are not compared.
+// Rows that differ only in the ignored columns are treated as equal.
+// Write the diff with WriteDiff, WriteJSON or WriteUnified,
+// or merge with MergeAll to use the setting.
+func (cmp *Compare) SetIgnoreColumns(names ...string) error {
+	pos := make([]int, 0, len(names))
+	for _, name := range names {
+		p, err := cmp.columnPos(name)
+		if err != nil {
+			return err
+		}
+		pos = append(pos, p)
+	}
+	cmp.ignore = pos
+	return nil
+}
+
+// SetCollation sets the collation of the text primary keys.
+// By default, the collation extra of t1 or t2 is used.
+func (cmp *Compare) SetCollation(name string) error {
+	coll, err := newCollation(name)
+	if err != nil {
+		return err
+	}
+	cmp.coll = coll
+	cmp.Collation = name
+	return nil
+}
+
+// SetComparator sets the Comparator that determines
+// whether the values of the column are equal.
+// The order of the primary key is not affected.
+// Equal values are not reported as changed by WriteDiff or MergeAll.
+func (cmp *Compare) SetComparator(name string, c Comparator) error {
+	p, err := cmp.columnPos(name)
+	if err != nil {
+		return err
+	}
+	if cmp.equal == nil {
+		cmp.equal = make(map[int]Comparator)
+	}
+	cmp.equal[p] = c
+	return nil
+}
+
+// columnPos returns the position of the compared column.
+func (cmp *Compare) columnPos(name string) (int, error) {
+	p := slices.Index(cmp.Columns, name)
+	if p < 0 {
+		return 0, fmt.Errorf("no column: %s", name)
+	}
+	return p, nil
+}
+
+// setColumns aligns the columns of t1 and t2 by name.
+func (cmp *Compare) setColumns() error {
+	names1 := cmp.t1.GetDefinition().Names()
+	names2 := cmp.t2.GetDefinition().Names()
+	cmp.Columns = names1
+	if len(names1) == 0 {
+		cmp.Columns = names2
+	}
+	if len(names1) == 0 || len(names2) == 0 || slices.Equal(names1, names2) {
+		return nil
+	}
+	var columns []string
+	for i, name := range names1 {
+		p := slices.Index(names2, name)
+		if p < 0 {
+			cmp.RemovedColumns = append(cmp.RemovedColumns, name)
+			continue
+		}
+		columns = append(columns, name)
+		cmp.t1Pos = append(cmp.t1Pos, i)
+		cmp.t2Pos = append(cmp.t2Pos, p)
+	}
+	for _, name := range names2 {
+		if !slices.Contains(names1, name) {
+			cmp.AddedColumns = append(cmp.AddedColumns, name)
+		}
+	}
+	if len(columns) == 0 {
+		return fmt.Errorf("no common columns")
+	}
+	cmp.Columns = columns
+	return nil
+}
+
+// keepProjectRow returns the columns of row at pos.
+// The row is kept after the next ReadRow, so it is copied
+// if the Reader reuses the record and pos does not make a new row.
+func keepProjectRow(pos []int, row []string, reuse bool) []string {
+	if pos != nil {
+		return projectRow(pos, row)
+	}
+	return keepRow(reuse, row)
+}
+
+// projectRow returns the columns of row at pos.
+// If pos is nil, row is returned as is.
+func projectRow(pos []int, row []string) []string {
+	if pos == nil || row == nil {
+		return row
+	}
+	p := make([]string, len(pos))
+	for i, v := range pos {
+		if v < len(row) {
+			p[i] = row[v]
+		}
+	}
+	return p
+}
+
+// changedColumns returns the positions of the columns that differ.
+func (cmp *Compare) changedColumns(row1, row2 []string) []int {
+	var changed []int
+	for i := range max(len(row1), len(row2)) {
+		if slices.Contains(cmp.ignore, i) {
+			continue
+		}
+		if i >= len(row1) || i >= len(row2) {
+			changed = append(changed, i)
+			continue
+		}
+		if c, ok := cmp.equal[i]; ok {
+			if !c(row1[i], row2[i]) {
+				changed = append(changed, i)
+			}
+			continue
+		}
+		if row1[i] != row2[i] {
+			changed = append(changed, i)
+		}
+	}
+	return changed
+}
+
+// ColumnChanges returns the old and new values of the changed columns.
+// names is used for the column name, and can be nil.
+func (d *DiffRow) ColumnChanges(names []string) []ColumnChange {
+	changes := make([]ColumnChange, 0, len(d.Changed))
+	for _, p := range d.Changed {
+		c := ColumnChange{Pos: p}
+		if p < len(names) {
+			c.Name = names[p]
+		}
+		if p < len(d.Self) {
+			c.Old = d.Self[p]
+		}
+		if p < len(d.Other) {
+			c.New = d.Other[p]
+		}
+		changes = append(changes, c)
+	}
+	return changes
+}
+
+// readNext reads the next row of t projected to pos and
+// checks that it is not smaller than the previous row.
+func (cmp *Compare) readNext(t Reader, pos []int, prev []string, reuse bool) ([]string, error) {
+	row, err := t.ReadRow()
+	// Ignore EOF to continue reading both ends.
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	row = keepProjectRow(pos, row, reuse)
+	if len(row) > 0 && len(prev) > 0 && cmp.compareKey(prev, row) > 0 {
+		return nil, fmt.Errorf("not sorted by primary key: %s", row)
+	}
+	return row, nil
+}
+
 func (cmp *Compare) diffPrimaryKey() int {
 	if len(cmp.t1Row) == 0 {
 		return 1
@@ -114,13 +340,12 @@ func (cmp *Compare) diffPrimaryKey() int {
 	if len(cmp.t2Row) == 0 {
 		return -1
 	}
-	for _, pk := range cmp.PK {
-		ret := compareType(pk.Typ, cmp.t1Row[pk.Pos], cmp.t2Row[pk.Pos])
-		if ret != 0 {
-			return ret
-		}
-	}
-	return 0
+	return cmp.compareKey(cmp.t1Row, cmp.t2Row)
+}
+
+// compareKey compares the primary keys of two rows with the collation.
+func (cmp *Compare) compareKey(row1 []string, row2 []string) int {
+	return compareKey(cmp.PK, cmp.coll, row1, row2)
 }
 
 // ColumnPrimaryKey return  columns primary key.
@@ -138,81 +363,128 @@ func ColumnPrimaryKey(pKeys []Pkey, row []string) []string {
 func (cmp *Compare) getPK() ([]Pkey, error) {
 	t1d := cmp.t1.GetDefinition()
 	t2d := cmp.t2.GetDefinition()
-	var pos []int
-	t2Pos, err := t2d.GetPKeyPos()
-	if err == nil && len(t2Pos) > 0 {
-		pos = t2Pos
+	t1t := projectRow(cmp.t1Pos, t1d.Types())
+	t2t := projectRow(cmp.t2Pos, t2d.Types())
+
+	pkNames := t1d.extraRow("primarykey")
+	if t2pk := t2d.extraRow("primarykey"); len(pkNames) == 0 {
+		pkNames = t2pk
+	} else if len(t2pk) > 0 && !slices.Equal(pkNames, t2pk) {
+		return nil, fmt.Errorf("primary key position")
 	}
-	t1Pos, err := t1d.GetPKeyPos()
-	if err == nil && len(t1Pos) > 0 {
-		pos = t1Pos
+	var pos []int
+	for _, name := range pkNames {
+		p := slices.Index(cmp.Columns, name)
+		if p < 0 {
+			return nil, fmt.Errorf("no primary key column: %s", name)
+		}
+		pos = append(pos, p)
 	}
 	// If both do not have a primary key,
 	// use all columns as primary keys.
 	if len(pos) == 0 {
-		t1Pos = make([]int, t1d.ColumnNum())
-		for i := range t1d.ColumnNum() {
-			t1Pos[i] = i
+		num := t1d.ColumnNum()
+		if cmp.t1Pos != nil {
+			num = len(cmp.Columns)
 		}
-		t2Pos = make([]int, t2d.ColumnNum())
-		for i := range t2d.ColumnNum() {
-			t2Pos[i] = i
+		pos = make([]int, num)
+		for i := range num {
+			pos[i] = i
 		}
-		pos = t1Pos
 	}
 
-	if len(t1Pos) > 0 && len(t2Pos) > 0 {
-		if len(t1Pos) != len(t2Pos) {
-			return nil, fmt.Errorf("primary key position")
-		}
-		for i := range pos {
-			if t1Pos[i] != t2Pos[i] {
-				return nil, fmt.Errorf("primary key position")
-			}
-		}
-	}
 	pk := make([]Pkey, len(pos))
-	t1t := t1d.Types()
-	t2t := t2d.Types()
 	if (len(t1t) < len(pos)) || (len(t1t) != len(t2t)) {
 		return nil, fmt.Errorf("mismatch data type: %d:%d", len(t1t), len(t2t))
 	}
 	for i, v := range pos {
-		if t1t[i] != t2t[i] {
-			return nil, fmt.Errorf("mismatch data type: %s:%s", t1t[i], t2t[i])
+		if t1t[v] != t2t[v] {
+			return nil, fmt.Errorf("mismatch data type: %s:%s", t1t[v], t2t[v])
 		}
-		n := t1d.Names()
-		pk[i] = Pkey{v, n[i], t1t[i]}
+		pk[i] = Pkey{v, columnValue(cmp.Columns, v), t1t[v]}
 	}
 	return pk, nil
 }
 
 func compareType(dType string, t1 string, t2 string) int {
 	switch dType {
-	case "int":
+	case "int", "smallint", "integer", "bigint":
 		return compareInt(t1, t2)
-	case "bigint", "double precision", "numeric":
+	case "numeric", "decimal":
+		return compareDecimal(t1, t2)
+	case "double precision", "real", "float":
 		return compareFloat(t1, t2)
+	case "timestamp", "timestamp with time zone", "timestamp without time zone", "timestamptz", "date":
+		return compareTime(t1, t2)
+	case "bool", "boolean":
+		return compareBool(t1, t2)
+	case "uuid":
+		return strings.Compare(strings.ToLower(t1), strings.ToLower(t2))
 	default:
 		return strings.Compare(t1, t2)
 	}
 }
 
 func compareInt(t1 string, t2 string) int {
-	var err error
-	var a, b int
-	if a, err = strconv.Atoi(t1); err != nil {
+	if ret, ok := compareInt64(t1, t2); ok {
+		return ret
+	}
+	// Out of range of int64.
+	a, ok := new(big.Int).SetString(t1, 10)
+	if !ok {
 		return strings.Compare(t1, t2)
 	}
-	if b, err = strconv.Atoi(t2); err != nil {
+	b, ok := new(big.Int).SetString(t2, 10)
+	if !ok {
 		return strings.Compare(t1, t2)
 	}
+	return a.Cmp(b)
+}
+
+// compareInt64 compares t1 and t2 as int64.
+// ok is false if either is not an int64.
+func compareInt64(t1 string, t2 string) (int, bool) {
+	a, err := strconv.ParseInt(t1, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	b, err := strconv.ParseInt(t2, 10, 64)
+	if err != nil {
+		return 0, false
+	}
 	if a > b {
-		return 1
+		return 1, true
 	} else if a < b {
-		return -1
+		return -1, true
 	}
-	return 0
+	return 0, true
+}
+
+func compareDecimal(t1 string, t2 string) int {
+	if ret, ok := compareInt64(t1, t2); ok {
+		return ret
+	}
+	// Rounding to float64 keeps the order,
+	// so only the values that are equal as float64 need big.Rat.
+	// Inf and NaN are not decimals and are left to big.Rat.
+	if a, err := strconv.ParseFloat(t1, 64); err == nil && !math.IsInf(a, 0) {
+		if b, err := strconv.ParseFloat(t2, 64); err == nil && !math.IsInf(b, 0) {
+			if a > b {
+				return 1
+			} else if a < b {
+				return -1
+			}
+		}
+	}
+	a, ok := new(big.Rat).SetString(t1)
+	if !ok {
+		return strings.Compare(t1, t2)
+	}
+	b, ok := new(big.Rat).SetString(t2)
+	if !ok {
+		return strings.Compare(t1, t2)
+	}
+	return a.Cmp(b)
 }
 
 func compareFloat(t1 string, t2 string) int {
@@ -231,3 +503,66 @@ func compareFloat(t1 string, t2 string) int {
 	}
 	return 0
 }
+
+func compareTime(t1 string, t2 string) int {
+	a, err := parseTime(t1)
+	if err != nil {
+		return strings.Compare(t1, t2)
+	}
+	b, err := parseTime(t2)
+	if err != nil {
+		return strings.Compare(t1, t2)
+	}
+	return a.Compare(b)
+}
+
+// compareBool compares bool values, false is less than true.
+func compareBool(t1 string, t2 string) int {
+	a, err := parseBool(t1)
+	if err != nil {
+		return strings.Compare(t1, t2)
+	}
+	b, err := parseBool(t2)
+	if err != nil {
+		return strings.Compare(t1, t2)
+	}
+	switch {
+	case a == b:
+		return 0
+	case b:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// parseBool parses bool values including the PostgreSQL notation.
+func parseBool(str string) (bool, error) {
+	switch strings.ToLower(str) {
+	case "t", "true", "y", "yes", "on", "1":
+		return true, nil
+	case "f", "false", "n", "no", "off", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid bool: %s", str)
+}
+
+// timeLayouts is the layouts of timestamp and date values.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z07",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+// parseTime parses timestamp and date values.
+func parseTime(str string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, str); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time: %s", str)
+}
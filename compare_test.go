@@ -137,7 +137,7 @@ func TestCompare_ReadDiffRow(t *testing.T) {
 		{
 			name:    "test1",
 			fields:  fields{t1: NewReader(bytes.NewBufferString(TestData)), t2: NewReader(bytes.NewBufferString(TestData2))},
-			want:    &DiffRow{Les: 2, Self: []string{"1", "Bob", "19"}, Other: []string{"1", "Alice", "14"}},
+			want:    &DiffRow{Les: 2, Self: []string{"1", "Bob", "19"}, Other: []string{"1", "Alice", "14"}, Changed: []int{1, 2}},
 			wantErr: false,
 		},
 		{
@@ -254,6 +254,15 @@ func Test_getPK(t *testing.T) {
 			want:    []Pkey{{Pos: 0, Name: "id", Typ: "int"}},
 			wantErr: false,
 		},
+		{
+			name: "name",
+			args: args{
+				t1: NewReader(bytes.NewBufferString(TestErr)),
+				t2: NewReader(bytes.NewBufferString(TestErr)),
+			},
+			want:    []Pkey{{Pos: 1, Name: "name", Typ: "text"}},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		cmp, err := NewCompare(tt.args.t1, tt.args.t2)
@@ -297,3 +306,217 @@ func TestColumnPrimaryKey(t *testing.T) {
 		})
 	}
 }
+
+var TestDataUpdated = `; name: | id | name | updated_at |
+; type: | int | text | timestamp |
+; primarykey: | id |
+| 1 | Bob | 2019-04-01T00:00:00+09:00 |
+| 2 | Alice | 2019-04-01T00:00:00+09:00 |
+`
+
+var TestDataUpdated2 = `; name: | id | name | updated_at |
+; type: | int | text | timestamp |
+; primarykey: | id |
+| 1 | Bob | 2019-04-02T00:00:00+09:00 |
+| 2 | Alicia | 2019-04-02T00:00:00+09:00 |
+`
+
+func TestCompare_SetIgnoreColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		ignore  []string
+		want    []*DiffRow
+		wantErr bool
+	}{
+		{
+			name:   "noIgnore",
+			ignore: nil,
+			want: []*DiffRow{
+				{Les: 2, Self: []string{"1", "Bob", "2019-04-01T00:00:00+09:00"}, Other: []string{"1", "Bob", "2019-04-02T00:00:00+09:00"}, Changed: []int{2}},
+				{Les: 2, Self: []string{"2", "Alice", "2019-04-01T00:00:00+09:00"}, Other: []string{"2", "Alicia", "2019-04-02T00:00:00+09:00"}, Changed: []int{1, 2}},
+			},
+		},
+		{
+			name:   "ignoreUpdatedAt",
+			ignore: []string{"updated_at"},
+			want: []*DiffRow{
+				{Les: 0, Self: []string{"1", "Bob", "2019-04-01T00:00:00+09:00"}, Other: []string{"1", "Bob", "2019-04-02T00:00:00+09:00"}},
+				{Les: 2, Self: []string{"2", "Alice", "2019-04-01T00:00:00+09:00"}, Other: []string{"2", "Alicia", "2019-04-02T00:00:00+09:00"}, Changed: []int{1}},
+			},
+		},
+		{
+			name:    "noColumn",
+			ignore:  []string{"created_at"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmp, err := NewCompare(NewReader(bytes.NewBufferString(TestDataUpdated)), NewReader(bytes.NewBufferString(TestDataUpdated2)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := cmp.SetIgnoreColumns(tt.ignore...); (err != nil) != tt.wantErr {
+				t.Fatalf("Compare.SetIgnoreColumns() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			for _, want := range tt.want {
+				got, err := cmp.ReadDiffRow()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("Compare.ReadDiffRow() = %v, want %v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDiffRow_ColumnChanges(t *testing.T) {
+	d := &DiffRow{Les: 2, Self: []string{"1", "Bob", "19"}, Other: []string{"1", "Henry", "20"}, Changed: []int{1, 2}}
+	want := []ColumnChange{
+		{Pos: 1, Name: "name", Old: "Bob", New: "Henry"},
+		{Pos: 2, Name: "age", Old: "19", New: "20"},
+	}
+	if got := d.ColumnChanges([]string{"id", "name", "age"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffRow.ColumnChanges() = %v, want %v", got, want)
+	}
+}
+
+func TestCompare_Columns(t *testing.T) {
+	t1 := `; name: | id | name | age |
+; type: | int | text | int |
+; primarykey: | id |
+| 1 | Bob | 19 |
+| 2 | Alice | 14 |
+`
+	tests := []struct {
+		name        string
+		t2          string
+		wantColumns []string
+		wantAdded   []string
+		wantRemoved []string
+		want        []*DiffRow
+		wantDiff    string
+	}{
+		{
+			name: "reorder",
+			t2: `; name: | name | age | id |
+; type: | text | int | int |
+; primarykey: | id |
+| Bob | 19 | 1 |
+| Alice | 15 | 2 |
+`,
+			wantColumns: []string{"id", "name", "age"},
+			want: []*DiffRow{
+				{Les: 0, Self: []string{"1", "Bob", "19"}, Other: []string{"1", "Bob", "19"}},
+				{Les: 2, Self: []string{"2", "Alice", "14"}, Other: []string{"2", "Alice", "15"}, Changed: []int{2}},
+			},
+			wantDiff: `-| 2 | Alice | 14 |
++| 2 | Alice | 15 |
+`,
+		},
+		{
+			name: "overlap",
+			t2: `; name: | id | email | name |
+; type: | int | text | text |
+; primarykey: | id |
+| 1 | bob@example.com | Bob |
+| 3 | carol@example.com | Carol |
+`,
+			wantColumns: []string{"id", "name"},
+			wantAdded:   []string{"email"},
+			wantRemoved: []string{"age"},
+			want: []*DiffRow{
+				{Les: 0, Self: []string{"1", "Bob"}, Other: []string{"1", "Bob"}},
+				{Les: -1, Self: []string{"2", "Alice"}},
+				{Les: 1, Other: []string{"3", "Carol"}},
+			},
+			wantDiff: `# removed columns: | age |
+# added columns: | email |
+-| 2 | Alice |
++| 3 | Carol |
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmp, err := NewCompare(NewReader(bytes.NewBufferString(t1)), NewReader(bytes.NewBufferString(tt.t2)))
+			if err != nil {
+				t.Fatalf("NewCompare() error = %v", err)
+			}
+			if !reflect.DeepEqual(cmp.Columns, tt.wantColumns) {
+				t.Errorf("Compare.Columns = %v, want %v", cmp.Columns, tt.wantColumns)
+			}
+			if !reflect.DeepEqual(cmp.AddedColumns, tt.wantAdded) {
+				t.Errorf("Compare.AddedColumns = %v, want %v", cmp.AddedColumns, tt.wantAdded)
+			}
+			if !reflect.DeepEqual(cmp.RemovedColumns, tt.wantRemoved) {
+				t.Errorf("Compare.RemovedColumns = %v, want %v", cmp.RemovedColumns, tt.wantRemoved)
+			}
+			if want := []Pkey{{Pos: 0, Name: "id", Typ: "int"}}; !reflect.DeepEqual(cmp.PK, want) {
+				t.Errorf("Compare.PK = %v, want %v", cmp.PK, want)
+			}
+			for _, want := range tt.want {
+				got, err := cmp.ReadDiffRow()
+				if err != nil {
+					t.Fatalf("Compare.ReadDiffRow() error = %v", err)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("Compare.ReadDiffRow() = %v, want %v", got, want)
+				}
+			}
+			if _, err := cmp.ReadDiffRow(); err != io.EOF {
+				t.Errorf("Compare.ReadDiffRow() error = %v, want EOF", err)
+			}
+
+			var buf bytes.Buffer
+			if err := DiffAll(&buf, NewReader(bytes.NewBufferString(t1)), NewReader(bytes.NewBufferString(tt.t2)), OnlyDiff); err != nil {
+				t.Fatalf("DiffAll() error = %v", err)
+			}
+			if buf.String() != tt.wantDiff {
+				t.Errorf("DiffAll() = \n%v, want \n%v", buf.String(), tt.wantDiff)
+			}
+		})
+	}
+}
+
+func Test_compareType(t *testing.T) {
+	tests := []struct {
+		dType string
+		t1    string
+		t2    string
+		want  int
+	}{
+		{dType: "int", t1: "2", t2: "10", want: -1},
+		{dType: "bigint", t1: "9007199254740993", t2: "9007199254740992", want: 1},
+		{dType: "bigint", t1: "123456789012345678901234567890", t2: "123456789012345678901234567890", want: 0},
+		{dType: "numeric", t1: "0.30000000000000000001", t2: "0.3", want: 1},
+		{dType: "numeric", t1: "1.0", t2: "1", want: 0},
+		{dType: "numeric", t1: "-3", t2: "2", want: -1},
+		{dType: "numeric", t1: "2.5", t2: "10", want: -1},
+		{dType: "numeric", t1: "99999999999999999999", t2: "100000000000000000000", want: -1},
+		{dType: "bigint", t1: "9223372036854775807", t2: "9223372036854775808", want: -1},
+		{dType: "bigint", t1: "-9223372036854775809", t2: "-9223372036854775808", want: -1},
+		{dType: "double precision", t1: "1.5", t2: "10", want: -1},
+		{dType: "timestamp with time zone", t1: "2019-01-01T09:00:00+09:00", t2: "2019-01-01T00:00:00Z", want: 0},
+		{dType: "timestamp with time zone", t1: "2019-01-01 10:00:00+09", t2: "2019-01-01T00:00:00Z", want: 1},
+		{dType: "timestamp", t1: "2019-01-02 00:00:00", t2: "2019-01-10 00:00:00", want: -1},
+		{dType: "date", t1: "2019-02-01", t2: "2019-10-01", want: -1},
+		{dType: "bool", t1: "t", t2: "false", want: 1},
+		{dType: "boolean", t1: "f", t2: "FALSE", want: 0},
+		{dType: "uuid", t1: "A0EEBC99-9C0B-4EF8-BB6D-6BB9BD380A11", t2: "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", want: 0},
+		{dType: "int", t1: "a", t2: "b", want: -1},
+		{dType: "text", t1: "b", t2: "a", want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dType, func(t *testing.T) {
+			if got := compareType(tt.dType, tt.t1, tt.t2); got != tt.want {
+				t.Errorf("compareType(%s, %s, %s) = %v, want %v", tt.dType, tt.t1, tt.t2, got, tt.want)
+			}
+		})
+	}
+}
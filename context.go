@@ -0,0 +1,53 @@
+package tbln
+
+import (
+	"context"
+	"io"
+)
+
+// ContextReader is a Reader that stops reading
+// when the context is canceled or the deadline is exceeded.
+type ContextReader struct {
+	Reader
+	ctx context.Context
+}
+
+// NewContextReader returns a new ContextReader that reads from r.
+func NewContextReader(ctx context.Context, r Reader) *ContextReader {
+	return &ContextReader{
+		Reader: r,
+		ctx:    ctx,
+	}
+}
+
+// ReadRow reads one record, or returns ctx.Err() if the context is done.
+func (cr *ContextReader) ReadRow() ([]string, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return nil, err
+	}
+	return cr.Reader.ReadRow()
+}
+
+func (cr *ContextReader) reusesRecord() bool {
+	return reusesRecord(cr.Reader)
+}
+
+// ReadAllContext is ReadAll with the context.
+func ReadAllContext(ctx context.Context, r io.Reader) (*TBLN, error) {
+	return readAllRows(NewContextReader(ctx, NewReader(r)))
+}
+
+// DiffAllContext is DiffAll with the context.
+func DiffAllContext(ctx context.Context, writer io.Writer, t1, t2 Reader, diffMode DiffMode) error {
+	return DiffAll(writer, NewContextReader(ctx, t1), NewContextReader(ctx, t2), diffMode)
+}
+
+// MergeAllContext is MergeAll with the context.
+func MergeAllContext(ctx context.Context, t1, t2 Reader, mode MergeMode) (*TBLN, error) {
+	return MergeAll(NewContextReader(ctx, t1), NewContextReader(ctx, t2), mode)
+}
+
+// ExceptAllContext is ExceptAll with the context.
+func ExceptAllContext(ctx context.Context, t1, t2 Reader) (*TBLN, error) {
+	return ExceptAll(NewContextReader(ctx, t1), NewContextReader(ctx, t2))
+}
@@ -0,0 +1,81 @@
+package tbln
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+// cancelReader cancels the context after reading n rows.
+type cancelReader struct {
+	Reader
+	n      int
+	cancel context.CancelFunc
+}
+
+func (cr *cancelReader) ReadRow() ([]string, error) {
+	if cr.n == 0 {
+		cr.cancel()
+	}
+	cr.n--
+	return cr.Reader.ReadRow()
+}
+
+func TestContext(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(ctx context.Context, t1, t2 Reader) error
+	}{
+		{
+			name: "DiffAllContext",
+			fn: func(ctx context.Context, t1, t2 Reader) error {
+				return DiffAllContext(ctx, io.Discard, t1, t2, AllDiff)
+			},
+		},
+		{
+			name: "MergeAllContext",
+			fn: func(ctx context.Context, t1, t2 Reader) error {
+				_, err := MergeAllContext(ctx, t1, t2, MergeUpdate)
+				return err
+			},
+		},
+		{
+			name: "ExceptAllContext",
+			fn: func(ctx context.Context, t1, t2 Reader) error {
+				_, err := ExceptAllContext(ctx, t1, t2)
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.fn(context.Background(), NewReader(bytes.NewBufferString(TestSet1)), NewReader(bytes.NewBufferString(TestSet2))); err != nil {
+				t.Fatalf("%s() error = %v", tt.name, err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			t1 := &cancelReader{Reader: NewReader(bytes.NewBufferString(TestSet1)), n: 1, cancel: cancel}
+			err := tt.fn(ctx, t1, NewReader(bytes.NewBufferString(TestSet2)))
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("%s() error = %v, want %v", tt.name, err, context.Canceled)
+			}
+		})
+	}
+}
+
+func TestReadAllContext(t *testing.T) {
+	tb, err := ReadAllContext(context.Background(), bytes.NewBufferString(TestSet1))
+	if err != nil {
+		t.Fatalf("ReadAllContext() error = %v", err)
+	}
+	if tb.RowNum != 3 || tb.TableName() != "test1" {
+		t.Errorf("ReadAllContext() = %v", tb)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ReadAllContext(ctx, bytes.NewBufferString(TestSet1)); !errors.Is(err, context.Canceled) {
+		t.Errorf("ReadAllContext() error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -1,8 +1,12 @@
 package tbln
 
 import (
+	"bytes"
 	"encoding/hex"
 	"fmt"
+	"maps"
+	"slices"
+	"strconv"
 	"time"
 )
 
@@ -32,6 +36,31 @@ func NewDefinition() *Definition {
 	}
 }
 
+// Clone returns a deep copy of the Definition.
+func (d *Definition) Clone() *Definition {
+	c := &Definition{
+		columnNum: d.columnNum,
+		tableName: d.tableName,
+		algorithm: d.algorithm,
+		Comments:  slices.Clone(d.Comments),
+		names:     slices.Clone(d.names),
+		types:     slices.Clone(d.types),
+		Extras:    maps.Clone(d.Extras),
+		Hashes:    make(map[string][]byte, len(d.Hashes)),
+		Signs:     make(Signatures, len(d.Signs)),
+	}
+	if c.Extras == nil {
+		c.Extras = make(map[string]Extra)
+	}
+	for k, v := range d.Hashes {
+		c.Hashes[k] = bytes.Clone(v)
+	}
+	for k, v := range d.Signs {
+		c.Signs[k] = Signature{sign: bytes.Clone(v.sign), algorithm: v.algorithm}
+	}
+	return c
+}
+
 // Extra is table definition extra struct.
 type Extra struct {
 	value      any
@@ -57,6 +86,16 @@ func (d *Definition) ExtraValue(key string) any {
 	return ext.Value()
 }
 
+// extraRow returns the extra value split as a row.
+// It returns nil if there is no extra.
+func (d *Definition) extraRow(key string) []string {
+	ext, ok := d.Extras[key]
+	if !ok {
+		return nil
+	}
+	return SplitRow(fmt.Sprintf("%s", ext.Value()))
+}
+
 // Signature algorithm.
 const (
 	ED25519 = "ED25519"
@@ -170,6 +209,11 @@ func (d *Definition) SerializeHash() []byte {
 	if val, ok := d.Hashes["sha512"]; ok {
 		hashes = append(hashes, "sha512:"+fmt.Sprintf("%x", val))
 	}
+	// The merkle root and the number of rows are signed directly
+	// so that rows can be verified alone.
+	if hashType, rowNum, root, err := d.MerkleRoot(); err == nil {
+		hashes = append(hashes, "merkle_"+hashType+":"+strconv.Itoa(rowNum)+":"+fmt.Sprintf("%x", root))
+	}
 	return []byte(JoinRow(hashes))
 }
 
@@ -225,6 +269,55 @@ func (d *Definition) GetPKeyPos() ([]int, error) {
 	return pos, nil
 }
 
+// isEmpty returns true if the Definition has nothing.
+func (d *Definition) isEmpty() bool {
+	return len(d.Comments) == 0 && len(d.Extras) == 0 && len(d.Hashes) == 0 && len(d.Signs) == 0
+}
+
+// columnPos returns the positions of the column names.
+func (d *Definition) columnPos(columns []string) ([]int, error) {
+	pos := make([]int, 0, len(columns))
+	for _, c := range columns {
+		n := slices.Index(d.names, c)
+		if n < 0 {
+			return nil, fmt.Errorf("no column: %s", c)
+		}
+		pos = append(pos, n)
+	}
+	return pos, nil
+}
+
+// keyColumns returns the columns as the keys for comparison.
+// If columns is empty, the primary key is used,
+// and if there is no primary key, all columns are used.
+func (d *Definition) keyColumns(columns []string) ([]Pkey, error) {
+	var pos []int
+	if len(columns) > 0 {
+		var err error
+		if pos, err = d.columnPos(columns); err != nil {
+			return nil, err
+		}
+	} else if pkPos, err := d.GetPKeyPos(); err == nil && len(pkPos) > 0 {
+		pos = pkPos
+	} else {
+		pos = make([]int, d.ColumnNum())
+		for i := range pos {
+			pos[i] = i
+		}
+	}
+	pk := make([]Pkey, len(pos))
+	for i, v := range pos {
+		pk[i] = Pkey{Pos: v}
+		if v < len(d.names) {
+			pk[i].Name = d.names[v]
+		}
+		if v < len(d.types) {
+			pk[i].Typ = d.types[v]
+		}
+	}
+	return pk, nil
+}
+
 // GetDefinition return Definition
 func (d *Definition) GetDefinition() *Definition {
 	return d
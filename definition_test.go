@@ -237,3 +237,22 @@ func TestDefinition_SetTimeStamp(t *testing.T) {
 		})
 	}
 }
+
+func TestDefinition_Clone(t *testing.T) {
+	d := NewDefinition()
+	d.SetTableName("test1")
+	if err := d.SetNames([]string{"id", "name"}); err != nil {
+		t.Fatal(err)
+	}
+	d.Hashes["sha256"] = []byte("hash")
+	c := d.Clone()
+	if !reflect.DeepEqual(c, d) {
+		t.Errorf("Definition.Clone() = %v, want %v", c, d)
+	}
+	c.SetExtra("primarykey", "| id |")
+	c.names[0] = "changed"
+	c.Hashes["sha256"][0] = 'x'
+	if d.ExtraValue("primarykey") != nil || d.names[0] != "id" || string(d.Hashes["sha256"]) != "hash" {
+		t.Errorf("Definition.Clone() modified the original %v", d)
+	}
+}
@@ -13,6 +13,7 @@ const (
 	OnlyAdd DiffMode = iota
 	OnlyDiff
 	AllDiff
+	CellDiff
 )
 
 func (m DiffMode) String() string {
@@ -23,6 +24,8 @@ func (m DiffMode) String() string {
 		return "OnlyDiff"
 	case AllDiff:
 		return "AllDiff"
+	case CellDiff:
+		return "CellDiff"
 	default:
 		return "Unknown"
 	}
@@ -41,7 +44,7 @@ func (d *DiffRow) Diff(diffMode DiffMode) string {
 		}
 		return fmt.Sprintf("+%s", JoinRow(d.Other))
 	case -1:
-		if diffMode == AllDiff || diffMode == OnlyDiff {
+		if diffMode == AllDiff || diffMode == OnlyDiff || diffMode == CellDiff {
 			return fmt.Sprintf("-%s", JoinRow(d.Self))
 		}
 	case 2:
@@ -49,6 +52,9 @@ func (d *DiffRow) Diff(diffMode DiffMode) string {
 		if diffMode == OnlyAdd {
 			return JoinRow(d.Other)
 		}
+		if diffMode == CellDiff {
+			return fmt.Sprintf("~%s", JoinRow(d.cellDiff()))
+		}
 		if diffMode == AllDiff || diffMode == OnlyDiff {
 			str = fmt.Sprintf("-%s\n", JoinRow(d.Self))
 		}
@@ -59,21 +65,50 @@ func (d *DiffRow) Diff(diffMode DiffMode) string {
 	return ""
 }
 
+// cellDiff returns the row that highlights only the changed cells
+// as [-old-]{+new+}.
+func (d *DiffRow) cellDiff() []string {
+	row := make([]string, len(d.Other))
+	copy(row, d.Other)
+	for _, c := range d.ColumnChanges(nil) {
+		if c.Pos < len(row) {
+			row[c.Pos] = fmt.Sprintf("[-%s-]{+%s+}", c.Old, c.New)
+		}
+	}
+	return row
+}
+
 // DiffAll Write diff to writer from two readers.
+// The added and removed columns are written as comments.
+// Use Compare.WriteDiff to ignore columns or to set comparators.
 func DiffAll(writer io.Writer, t1, t2 Reader, diffMode DiffMode) error {
-	d, err := NewCompare(t1, t2)
+	cmp, err := NewCompare(t1, t2)
 	if err != nil {
 		return err
 	}
+	return cmp.WriteDiff(writer, diffMode)
+}
+
+// WriteDiff writes the diff of the remaining rows to writer as DiffAll does,
+// with the ignored columns, comparators and collation set to cmp.
+func (cmp *Compare) WriteDiff(writer io.Writer, diffMode DiffMode) error {
+	if len(cmp.RemovedColumns) > 0 {
+		fmt.Fprintf(writer, "# removed columns: %s\n", JoinRow(cmp.RemovedColumns))
+	}
+	if len(cmp.AddedColumns) > 0 {
+		fmt.Fprintf(writer, "# added columns: %s\n", JoinRow(cmp.AddedColumns))
+	}
 	for {
-		dd, err := d.ReadDiffRow()
+		dd, err := cmp.ReadDiffRow()
 		if err != nil {
-			break
+			if err == io.EOF {
+				return nil
+			}
+			return err
 		}
 		dString := dd.Diff(diffMode)
 		if dString != "" {
 			fmt.Fprintf(writer, "%s\n", dString)
 		}
 	}
-	return err
 }
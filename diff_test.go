@@ -41,6 +41,9 @@ var TestAllDiff3 = `-| 1 | Bob | 19 |
  | 2 | Alice | 14 |
 `
 
+var TestCellDiff3 = `~| 1 | [-Bob-]{+Henry+} | 19 |
+`
+
 func TestDiffAll(t *testing.T) {
 	type args struct {
 		t1       Reader
@@ -71,6 +74,12 @@ func TestDiffAll(t *testing.T) {
 			wantWriter: TestAllDiff3,
 			wantErr:    false,
 		},
+		{
+			name:       "testCell",
+			args:       args{t1: NewReader(bytes.NewBufferString(TestDiff2)), t2: NewReader(bytes.NewBufferString(TestDiff3)), diffMode: CellDiff},
+			wantWriter: TestCellDiff3,
+			wantErr:    false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -85,3 +94,56 @@ func TestDiffAll(t *testing.T) {
 		})
 	}
 }
+
+func TestCompare_WriteDiff(t *testing.T) {
+	tests := []struct {
+		name       string
+		write      func(cmp *Compare, w *bytes.Buffer) error
+		wantWriter string
+	}{
+		{
+			name:       "diff",
+			write:      func(cmp *Compare, w *bytes.Buffer) error { return cmp.WriteDiff(w, OnlyDiff) },
+			wantWriter: "-| 2 | Alice | 2019-04-01T00:00:00+09:00 |\n+| 2 | Alicia | 2019-04-02T00:00:00+09:00 |\n",
+		},
+		{
+			name:       "jsonLines",
+			write:      func(cmp *Compare, w *bytes.Buffer) error { return cmp.WriteJSONLines(w, OnlyDiff) },
+			wantWriter: "{\"op\":\"update\",\"key\":[\"2\"],\"old\":{\"id\":\"2\",\"name\":\"Alice\",\"updated_at\":\"2019-04-01T00:00:00+09:00\"},\"new\":{\"id\":\"2\",\"name\":\"Alicia\",\"updated_at\":\"2019-04-02T00:00:00+09:00\"},\"changed\":[\"name\"]}\n",
+		},
+		{
+			name:       "unified",
+			write:      func(cmp *Compare, w *bytes.Buffer) error { return cmp.WriteUnified(w, 0) },
+			wantWriter: "--- a\n+++ b\n@@ -5,1 +5,1 @@\n-| 2 | Alice | 2019-04-01T00:00:00+09:00 |\n+| 2 | Alicia | 2019-04-02T00:00:00+09:00 |\n",
+		},
+		{
+			name: "merge",
+			write: func(cmp *Compare, w *bytes.Buffer) error {
+				tb, err := cmp.MergeAll(MergeUpdate)
+				if err != nil {
+					return err
+				}
+				return WriteAll(w, tb)
+			},
+			wantWriter: "; name: | id | name | updated_at |\n; primarykey: | id |\n; type: | int | text | timestamp |\n| 1 | Bob | 2019-04-01T00:00:00+09:00 |\n| 2 | Alicia | 2019-04-02T00:00:00+09:00 |\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmp, err := NewCompare(NewReader(bytes.NewBufferString(TestDataUpdated)), NewReader(bytes.NewBufferString(TestDataUpdated2)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := cmp.SetIgnoreColumns("updated_at"); err != nil {
+				t.Fatal(err)
+			}
+			writer := &bytes.Buffer{}
+			if err := tt.write(cmp, writer); err != nil {
+				t.Fatal(err)
+			}
+			if gotWriter := writer.String(); gotWriter != tt.wantWriter {
+				t.Errorf("Compare.WriteDiff() = %q, want %q", gotWriter, tt.wantWriter)
+			}
+		})
+	}
+}
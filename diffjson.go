@@ -0,0 +1,127 @@
+package tbln
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// JSONDiffRow is the JSON representation of DiffRow.
+// Old and New are keyed by the column name.
+type JSONDiffRow struct {
+	Op      string            `json:"op"`
+	Key     []string          `json:"key"`
+	Old     map[string]string `json:"old,omitempty"`
+	New     map[string]string `json:"new,omitempty"`
+	Changed []string          `json:"changed,omitempty"`
+}
+
+// JSONDiffRow returns the JSON representation of d.
+func (cmp *Compare) JSONDiffRow(d *DiffRow) *JSONDiffRow {
+	j := &JSONDiffRow{
+		Old: cmp.columnMap(d.Self),
+		New: cmp.columnMap(d.Other),
+	}
+	switch d.Les {
+	case 0:
+		j.Op = "equal"
+	case 1:
+		j.Op = "insert"
+	case -1:
+		j.Op = "delete"
+	case 2:
+		j.Op = "update"
+	}
+	if d.Self != nil {
+		j.Key = ColumnPrimaryKey(cmp.PK, d.Self)
+	} else {
+		j.Key = ColumnPrimaryKey(cmp.PK, d.Other)
+	}
+	for _, p := range d.Changed {
+		j.Changed = append(j.Changed, cmp.columnName(p))
+	}
+	return j
+}
+
+func (cmp *Compare) columnMap(row []string) map[string]string {
+	if row == nil {
+		return nil
+	}
+	m := make(map[string]string, len(row))
+	for i, v := range row {
+		m[cmp.columnName(i)] = v
+	}
+	return m
+}
+
+// DiffAllJSON writes diff to writer as a JSON array from two readers.
+func DiffAllJSON(writer io.Writer, t1, t2 Reader, diffMode DiffMode) error {
+	cmp, err := NewCompare(t1, t2)
+	if err != nil {
+		return err
+	}
+	return cmp.WriteJSON(writer, diffMode)
+}
+
+// DiffAllJSONLines writes diff to writer as JSON Lines from two readers.
+func DiffAllJSONLines(writer io.Writer, t1, t2 Reader, diffMode DiffMode) error {
+	cmp, err := NewCompare(t1, t2)
+	if err != nil {
+		return err
+	}
+	return cmp.WriteJSONLines(writer, diffMode)
+}
+
+// WriteJSON writes the diff of the remaining rows as a JSON array
+// as DiffAllJSON does, with the options set to cmp.
+func (cmp *Compare) WriteJSON(writer io.Writer, diffMode DiffMode) error {
+	if _, err := io.WriteString(writer, "["); err != nil {
+		return err
+	}
+	sep := "\n"
+	err := cmp.writeJSON(diffMode, func(b []byte) error {
+		if _, err := io.WriteString(writer, sep); err != nil {
+			return err
+		}
+		sep = ",\n"
+		_, err := writer.Write(b)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(writer, "\n]\n")
+	return err
+}
+
+// WriteJSONLines writes the diff of the remaining rows as JSON Lines
+// as DiffAllJSONLines does, with the options set to cmp.
+func (cmp *Compare) WriteJSONLines(writer io.Writer, diffMode DiffMode) error {
+	return cmp.writeJSON(diffMode, func(b []byte) error {
+		_, err := writer.Write(append(b, '\n'))
+		return err
+	})
+}
+
+// writeJSON calls write with the JSON of each DiffRow.
+// The rows are selected by diffMode as DiffAll.
+func (cmp *Compare) writeJSON(diffMode DiffMode, write func([]byte) error) error {
+	for {
+		dd, err := cmp.ReadDiffRow()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if dd.Diff(diffMode) == "" {
+			continue
+		}
+		b, err := json.Marshal(cmp.JSONDiffRow(dd))
+		if err != nil {
+			return err
+		}
+		if err := write(b); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package tbln
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDiffAllJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := DiffAllJSON(&buf, NewReader(bytes.NewBufferString(TestSet1)), NewReader(bytes.NewBufferString(TestSet2)), OnlyDiff); err != nil {
+		t.Fatalf("DiffAllJSON() error = %v", err)
+	}
+	want := `[
+{"op":"update","key":["2"],"old":{"age":"14","id":"2","name":"Alice"},"new":{"age":"15","id":"2","name":"Alice"},"changed":["age"]},
+{"op":"delete","key":["3"],"old":{"age":"19","id":"3","name":"Henry"}},
+{"op":"insert","key":["4"],"new":{"age":"30","id":"4","name":"Carol"}}
+]
+`
+	if got := buf.String(); got != want {
+		t.Errorf("DiffAllJSON() = \n%v, want \n%v", got, want)
+	}
+
+	buf.Reset()
+	if err := DiffAllJSON(&buf, NewReader(bytes.NewBufferString(TestDiff1)), NewReader(bytes.NewBufferString(TestDiff1)), OnlyDiff); err != nil {
+		t.Fatalf("DiffAllJSON() error = %v", err)
+	}
+	if got := buf.String(); got != "[\n]\n" {
+		t.Errorf("DiffAllJSON() = %v, want empty array", got)
+	}
+}
+
+func TestDiffAllJSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	if err := DiffAllJSONLines(&buf, NewReader(bytes.NewBufferString(TestSet1)), NewReader(bytes.NewBufferString(TestSet2)), AllDiff); err != nil {
+		t.Fatalf("DiffAllJSONLines() error = %v", err)
+	}
+	want := `{"op":"equal","key":["1"],"old":{"age":"19","id":"1","name":"Bob"},"new":{"age":"19","id":"1","name":"Bob"}}
+{"op":"update","key":["2"],"old":{"age":"14","id":"2","name":"Alice"},"new":{"age":"15","id":"2","name":"Alice"},"changed":["age"]}
+{"op":"delete","key":["3"],"old":{"age":"19","id":"3","name":"Henry"}}
+{"op":"insert","key":["4"],"new":{"age":"30","id":"4","name":"Carol"}}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("DiffAllJSONLines() = \n%v, want \n%v", got, want)
+	}
+}
@@ -0,0 +1,235 @@
+package tbln
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/nacl/box"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// Encryption scheme.
+const (
+	// NaClSecretBox encrypts the cells with a data key sealed for each recipient.
+	NaClSecretBox = "nacl-secretbox"
+	// NaClSecretBoxKey encrypts the cells directly with a shared key.
+	NaClSecretBoxKey = "nacl-secretbox-key"
+)
+
+// Extra names of the encryption.
+// The definition is left readable and only the cells are encrypted.
+const (
+	// EncryptionKey is written as | scheme | plaintext or ciphertext |.
+	EncryptionKey = "encryption"
+	// EncryptedColumnsKey is the names of the encrypted columns.
+	EncryptedColumnsKey = "encrypted_columns"
+	// RecipientsKey is the data key sealed for each recipient as | name:key |.
+	RecipientsKey = "recipients"
+)
+
+// EncryptHash represents what the hashes and signatures are defined over.
+type EncryptHash int
+
+// Represents the target of the hash of the encrypted TBLN
+const (
+	// HashPlaintext keeps the hashes and signatures of the plaintext.
+	// They can be verified after decryption.
+	HashPlaintext EncryptHash = iota
+	// HashCiphertext calculates the hashes over the encrypted TBLN.
+	// They can be verified (and signed) without decryption.
+	HashCiphertext
+)
+
+func (h EncryptHash) String() string {
+	switch h {
+	case HashPlaintext:
+		return "plaintext"
+	case HashCiphertext:
+		return "ciphertext"
+	default:
+		return "Unknown"
+	}
+}
+
+// Recipient is the name and the public key of the recipient of the encrypted TBLN.
+type Recipient struct {
+	Name      string
+	PublicKey *[32]byte
+}
+
+// GenerateEncryptionKey generates a key pair of the recipient.
+func GenerateEncryptionKey() (publicKey, privateKey *[32]byte, err error) {
+	return box.GenerateKey(rand.Reader)
+}
+
+// Encrypt returns a new TBLN whose cells are encrypted to the recipients.
+// If columns is empty, all columns are encrypted.
+// The data key is random and sealed with the public key of each recipient.
+func (t *TBLN) Encrypt(recipients []Recipient, columns []string, hashMode EncryptHash) (*TBLN, error) {
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("no recipient")
+	}
+	pos, err := t.encryptPos(columns)
+	if err != nil {
+		return nil, err
+	}
+	var key [32]byte
+	if _, err := rand.Read(key[:]); err != nil {
+		return nil, err
+	}
+	sealed := make([]string, 0, len(recipients))
+	for _, r := range recipients {
+		if strings.Contains(r.Name, ":") {
+			return nil, fmt.Errorf("invalid recipient name: %s", r.Name)
+		}
+		b, err := box.SealAnonymous(nil, key[:], r.PublicKey, rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		sealed = append(sealed, r.Name+":"+base64.StdEncoding.EncodeToString(b))
+	}
+
+	et := &TBLN{
+		Definition: t.Definition.Clone(),
+		RowNum:     t.RowNum,
+		Rows:       make([][]string, 0, len(t.Rows)),
+	}
+	for _, row := range t.Rows {
+		erow := make([]string, len(row))
+		copy(erow, row)
+		for _, p := range pos {
+			if erow[p], err = sealCell(&key, row[p]); err != nil {
+				return nil, err
+			}
+		}
+		et.Rows = append(et.Rows, erow)
+	}
+
+	target := hashMode == HashCiphertext
+	names := make([]string, 0, len(pos))
+	for _, p := range pos {
+		names = append(names, t.names[p])
+	}
+	et.Extras[EncryptionKey] = NewExtra(JoinRow([]string{NaClSecretBox, hashMode.String()}), target)
+	et.Extras[EncryptedColumnsKey] = NewExtra(JoinRow(names), target)
+	et.Extras[RecipientsKey] = NewExtra(JoinRow(sealed), target)
+	if target {
+		hashTypes := make([]string, 0, len(et.Hashes))
+		for k := range et.Hashes {
+			hashTypes = append(hashTypes, k)
+		}
+		et.Hashes = make(map[string][]byte)
+		et.Signs = make(Signatures)
+		for _, k := range hashTypes {
+			if err := et.SumHash(k); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return et, nil
+}
+
+// Decrypt returns a new TBLN decrypted with the key pair of the recipient name.
+// The extras of the encryption are removed.
+// The hashes over the ciphertext are also removed because they no longer match.
+func (t *TBLN) Decrypt(name string, publicKey, privateKey *[32]byte) (*TBLN, error) {
+	v := t.extraRow(EncryptionKey)
+	if len(v) != 2 {
+		return nil, fmt.Errorf("not encrypted")
+	}
+	if v[0] != NaClSecretBox {
+		return nil, fmt.Errorf("not support encryption: %s", v[0])
+	}
+	var sealed string
+	for _, r := range t.extraRow(RecipientsKey) {
+		if n, k, ok := strings.Cut(r, ":"); ok && n == name {
+			sealed = k
+		}
+	}
+	if sealed == "" {
+		return nil, fmt.Errorf("no recipient: %s", name)
+	}
+	b, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, err
+	}
+	k, ok := box.OpenAnonymous(nil, b, publicKey, privateKey)
+	if !ok || len(k) != 32 {
+		return nil, fmt.Errorf("decryption failed: %s", name)
+	}
+	var key [32]byte
+	copy(key[:], k)
+	pos, err := t.columnPos(t.extraRow(EncryptedColumnsKey))
+	if err != nil {
+		return nil, err
+	}
+
+	dt := &TBLN{
+		Definition: t.Definition.Clone(),
+		RowNum:     t.RowNum,
+		Rows:       make([][]string, 0, len(t.Rows)),
+	}
+	for _, row := range t.Rows {
+		drow := make([]string, len(row))
+		copy(drow, row)
+		for _, p := range pos {
+			if drow[p], err = openCell(&key, row[p]); err != nil {
+				return nil, err
+			}
+		}
+		dt.Rows = append(dt.Rows, drow)
+	}
+	delete(dt.Extras, EncryptionKey)
+	delete(dt.Extras, EncryptedColumnsKey)
+	delete(dt.Extras, RecipientsKey)
+	if v[1] != HashPlaintext.String() {
+		dt.Hashes = make(map[string][]byte)
+		dt.Signs = make(Signatures)
+	}
+	return dt, nil
+}
+
+// encryptPos returns the positions of the columns to be encrypted.
+func (d *Definition) encryptPos(columns []string) ([]int, error) {
+	if len(d.names) == 0 {
+		return nil, fmt.Errorf("no column name")
+	}
+	if len(columns) > 0 {
+		return d.columnPos(columns)
+	}
+	pos := make([]int, len(d.names))
+	for i := range pos {
+		pos[i] = i
+	}
+	return pos, nil
+}
+
+// sealCell encrypts a cell with a random nonce and returns base64.
+func sealCell(key *[32]byte, cell string) (string, error) {
+	var nonce [24]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return "", err
+	}
+	b := secretbox.Seal(nonce[:], []byte(cell), &nonce, key)
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// openCell decrypts a cell encrypted by sealCell.
+func openCell(key *[32]byte, cell string) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(cell)
+	if err != nil {
+		return "", err
+	}
+	if len(b) < 24 {
+		return "", fmt.Errorf("decryption failed")
+	}
+	var nonce [24]byte
+	copy(nonce[:], b[:24])
+	plain, ok := secretbox.Open(nil, b[24:], &nonce, key)
+	if !ok {
+		return "", fmt.Errorf("decryption failed")
+	}
+	return string(plain), nil
+}
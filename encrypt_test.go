@@ -0,0 +1,96 @@
+package tbln
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestTBLN_Encrypt(t *testing.T) {
+	alicePub, alicePriv, err := GenerateEncryptionKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bobPub, bobPriv, err := GenerateEncryptionKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, evePriv, err := GenerateEncryptionKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipients := []Recipient{{Name: "alice", PublicKey: alicePub}, {Name: "bob", PublicKey: bobPub}}
+	tests := []struct {
+		name     string
+		columns  []string
+		hashMode EncryptHash
+		plain    []int
+	}{
+		{name: "all", columns: nil, hashMode: HashPlaintext, plain: nil},
+		{name: "columns", columns: []string{"name"}, hashMode: HashPlaintext, plain: []int{0, 2}},
+		{name: "ciphertext", columns: []string{"name", "age"}, hashMode: HashCiphertext, plain: []int{0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb, err := ReadAll(bytes.NewBufferString(TestDiff2))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := tb.SumHash(SHA256); err != nil {
+				t.Fatal(err)
+			}
+			et, err := tb.Encrypt(recipients, tt.columns, tt.hashMode)
+			if err != nil {
+				t.Fatalf("TBLN.Encrypt() error = %v", err)
+			}
+			if !reflect.DeepEqual(et.Names(), tb.Names()) {
+				t.Errorf("TBLN.Encrypt() names = %v, want %v", et.Names(), tb.Names())
+			}
+			for i, row := range et.Rows {
+				for j, col := range row {
+					isPlain := false
+					for _, p := range tt.plain {
+						isPlain = isPlain || p == j
+					}
+					if (col == tb.Rows[i][j]) != isPlain {
+						t.Errorf("TBLN.Encrypt() cell %d,%d = %s", i, j, col)
+					}
+				}
+			}
+			if et.Verify() != (tt.hashMode == HashCiphertext) {
+				t.Errorf("TBLN.Encrypt() Verify = %v", et.Verify())
+			}
+
+			// Write and read back.
+			var buf bytes.Buffer
+			if err := WriteAll(&buf, et); err != nil {
+				t.Fatal(err)
+			}
+			rt, err := ReadAll(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, r := range []struct {
+				name      string
+				pub, priv *[32]byte
+			}{{"alice", alicePub, alicePriv}, {"bob", bobPub, bobPriv}} {
+				dt, err := rt.Decrypt(r.name, r.pub, r.priv)
+				if err != nil {
+					t.Fatalf("TBLN.Decrypt() error = %v", err)
+				}
+				if !reflect.DeepEqual(dt.Rows, tb.Rows) {
+					t.Errorf("TBLN.Decrypt() = %v, want %v", dt.Rows, tb.Rows)
+				}
+				if dt.Verify() != (tt.hashMode == HashPlaintext) {
+					t.Errorf("TBLN.Decrypt() Verify = %v", dt.Verify())
+				}
+			}
+			if _, err := rt.Decrypt("alice", alicePub, evePriv); err == nil {
+				t.Errorf("TBLN.Decrypt() wrong key error = nil")
+			}
+			if _, err := rt.Decrypt("eve", alicePub, alicePriv); err == nil {
+				t.Errorf("TBLN.Decrypt() no recipient error = nil")
+			}
+		})
+	}
+}
@@ -96,3 +96,36 @@ func ExampleDiffAll() {
 	//| 1 | Bob | 19 |
 	//+| 2 | Alice | 14 |
 }
+
+func ExampleCompare_WriteDiff() {
+	t1 := `; name: | id | price | updated_at |
+; type: | int | numeric | timestamp |
+; primarykey: | id |
+| 1 | 1.0 | 2019-04-01 |
+| 2 | 2.5 | 2019-04-01 |
+`
+	t2 := `; name: | id | price | updated_at |
+; type: | int | numeric | timestamp |
+; primarykey: | id |
+| 1 | 1 | 2019-04-02 |
+| 2 | 2.6 | 2019-04-02 |
+`
+	cmp, err := tbln.NewCompare(
+		tbln.NewReader(bytes.NewBufferString(t1)),
+		tbln.NewReader(bytes.NewBufferString(t2)))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := cmp.SetComparator("price", tbln.DecimalEqual); err != nil {
+		log.Fatal(err)
+	}
+	if err := cmp.SetIgnoreColumns("updated_at"); err != nil {
+		log.Fatal(err)
+	}
+	if err := cmp.WriteDiff(os.Stdout, tbln.OnlyDiff); err != nil {
+		log.Fatal(err)
+	}
+	// Output:
+	//-| 2 | 2.5 | 2019-04-01 |
+	//+| 2 | 2.6 | 2019-04-02 |
+}
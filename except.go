@@ -18,27 +18,23 @@ func (d *DiffRow) ExceptRow() []string {
 
 // ExceptAll merges two tbln and returns one tbln.
 func ExceptAll(t1, t2 Reader) (*TBLN, error) {
-	diff, err := NewCompare(t1, t2)
+	er, err := NewExceptReader(t1, t2)
 	if err != nil {
 		return nil, err
 	}
-	tb := NewTBLN()
-	tb.Definition = t2.GetDefinition()
-	tb.Definition.Hashes = make(map[string][]byte)
-	tb.Definition.Signs = make(Signatures)
-	tb.Rows = make([][]string, 0)
-	for {
-		dd, err := diff.ReadDiffRow()
-		if err != nil {
-			if err == io.EOF {
-				return tb, nil
-			}
-			return nil, err
-		}
-		row := dd.ExceptRow()
-		if row != nil {
-			tb.RowNum++
-			tb.Rows = append(tb.Rows, row)
-		}
+	return readAllRows(er)
+}
+
+// WriteExcept writes the rows of t1 that are not in t2 to writer.
+func WriteExcept(writer io.Writer, t1, t2 Reader) error {
+	er, err := NewExceptReader(t1, t2)
+	if err != nil {
+		return err
 	}
+	return WriteReader(writer, er)
+}
+
+// NewExceptReader returns a Reader that returns the rows of t1 that are not in t2.
+func NewExceptReader(t1, t2 Reader) (*SetReader, error) {
+	return NewSetReader(t1, t2, SetExcept)
 }
@@ -1,6 +1,11 @@
 module github.com/noborus/tbln
 
-require golang.org/x/crypto v0.45.0
+require (
+	golang.org/x/crypto v0.45.0
+	golang.org/x/text v0.31.0
+)
+
+require golang.org/x/sys v0.38.0 // indirect
 
 go 1.24.0
 
@@ -0,0 +1,76 @@
+package tbln
+
+import (
+	"io"
+	"iter"
+)
+
+// Rows returns an iterator over the records of r.
+// The iteration stops at io.EOF or a blank line.
+// If an error occurs, it is yielded with a nil record and the iteration stops.
+func Rows(r Reader) iter.Seq2[[]string, error] {
+	return func(yield func([]string, error) bool) {
+		for {
+			row, err := r.ReadRow()
+			if err != nil {
+				if err != io.EOF {
+					yield(nil, err)
+				}
+				return
+			}
+			if row == nil {
+				return
+			}
+			if !yield(row, nil) {
+				return
+			}
+		}
+	}
+}
+
+// All returns an iterator over the records of tr.
+func (tr *FileReader) All() iter.Seq2[[]string, error] {
+	return Rows(tr)
+}
+
+// Blocks returns an iterator over the blocks of tr.
+// See ReadBlock.
+func (tr *FileReader) Blocks() iter.Seq2[*TBLN, error] {
+	return func(yield func(*TBLN, error) bool) {
+		for {
+			tb, err := tr.ReadBlock()
+			if err != nil {
+				if err != io.EOF {
+					yield(nil, err)
+				}
+				return
+			}
+			if !yield(tb, nil) {
+				return
+			}
+		}
+	}
+}
+
+// All returns an iterator over the records of rr.
+func (rr *OwnReader) All() iter.Seq2[[]string, error] {
+	return Rows(rr)
+}
+
+// All returns an iterator over the DiffRows of cmp.
+func (cmp *Compare) All() iter.Seq2[*DiffRow, error] {
+	return func(yield func(*DiffRow, error) bool) {
+		for {
+			dd, err := cmp.ReadDiffRow()
+			if err != nil {
+				if err != io.EOF {
+					yield(nil, err)
+				}
+				return
+			}
+			if !yield(dd, nil) {
+				return
+			}
+		}
+	}
+}
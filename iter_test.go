@@ -0,0 +1,95 @@
+package tbln
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestFileReader_All(t *testing.T) {
+	var got [][]string
+	for row, err := range NewReader(bytes.NewBufferString(TestSet1)).All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, row)
+	}
+	want := [][]string{{"1", "Bob", "19"}, {"2", "Alice", "14"}, {"3", "Henry", "19"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FileReader.All() = %v, want %v", got, want)
+	}
+
+	// break stops the iteration.
+	n := 0
+	for range NewReader(bytes.NewBufferString(TestSet1)).All() {
+		n++
+		break
+	}
+	if n != 1 {
+		t.Errorf("FileReader.All() break = %d, want 1", n)
+	}
+
+	var gotErr error
+	for _, err := range NewReader(bytes.NewBufferString("| 1 |\n| 1 | 2 |\n")).All() {
+		gotErr = err
+	}
+	if gotErr == nil {
+		t.Errorf("FileReader.All() error = nil, want error")
+	}
+}
+
+func TestFileReader_Blocks(t *testing.T) {
+	src := `; TableName: t1
+| 1 |
+
+; TableName: t2
+| 2 |
+| 3 |
+`
+	var names []string
+	var rows []int
+	for tb, err := range NewReader(bytes.NewBufferString(src)).Blocks() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, tb.TableName())
+		rows = append(rows, tb.RowNum)
+	}
+	if !reflect.DeepEqual(names, []string{"t1", "t2"}) || !reflect.DeepEqual(rows, []int{1, 2}) {
+		t.Errorf("FileReader.Blocks() = %v %v", names, rows)
+	}
+}
+
+func TestOwnReader_All(t *testing.T) {
+	tb, err := ReadAll(bytes.NewBufferString(TestSet1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got [][]string
+	for row, err := range NewOwnReader(tb).All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, row)
+	}
+	if !reflect.DeepEqual(got, tb.Rows) {
+		t.Errorf("OwnReader.All() = %v, want %v", got, tb.Rows)
+	}
+}
+
+func TestCompare_All(t *testing.T) {
+	cmp, err := NewCompare(NewReader(bytes.NewBufferString(TestSet1)), NewReader(bytes.NewBufferString(TestSet2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var les []int
+	for dd, err := range cmp.All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		les = append(les, dd.Les)
+	}
+	if want := []int{0, 2, -1, 1}; !reflect.DeepEqual(les, want) {
+		t.Errorf("Compare.All() = %v, want %v", les, want)
+	}
+}
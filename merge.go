@@ -1,10 +1,11 @@
 package tbln
 
 import (
+	"errors"
 	"fmt"
 	"io"
-	"maps"
 	"slices"
+	"strings"
 )
 
 // MergeMode represents the mode of merge.
@@ -40,47 +41,212 @@ func (d *DiffRow) MergeRow(mode MergeMode) []string {
 }
 
 // MergeAll merges two tbln and returns one tbln.
+// Use Compare.MergeAll to ignore columns or to set comparators.
 func MergeAll(t1, t2 Reader, mode MergeMode) (*TBLN, error) {
-	tb := &TBLN{}
-	diff, err := NewCompare(t1, t2)
+	mr, err := NewMergeReader(t1, t2, mode)
 	if err != nil {
 		return nil, err
 	}
-	t1Definition := t1.GetDefinition()
-	t2Definition := t2.GetDefinition()
-	tb.Definition, err = MergeDefinition(t1Definition, t2Definition)
+	return readAllRows(mr)
+}
+
+// MergeAll merges the remaining rows as MergeAll does,
+// with the options set to cmp.
+func (cmp *Compare) MergeAll(mode MergeMode) (*TBLN, error) {
+	mr, err := cmp.MergeReader(mode)
 	if err != nil {
 		return nil, err
 	}
-	tb.Rows = make([][]string, 0)
+	return readAllRows(mr)
+}
+
+// WriteMerge writes the merged rows of two tbln to writer.
+func WriteMerge(writer io.Writer, t1, t2 Reader, mode MergeMode) error {
+	mr, err := NewMergeReader(t1, t2, mode)
+	if err != nil {
+		return err
+	}
+	return WriteReader(writer, mr)
+}
+
+// MergeReader is a Reader that returns the merged rows of two Readers.
+type MergeReader struct {
+	cmp  *Compare
+	mode MergeMode
+	def  *Definition
+}
+
+// NewMergeReader returns a new MergeReader.
+func NewMergeReader(t1, t2 Reader, mode MergeMode) (*MergeReader, error) {
+	cmp, err := NewCompare(t1, t2)
+	if err != nil {
+		return nil, err
+	}
+	return cmp.MergeReader(mode)
+}
+
+// MergeReader returns a new MergeReader of the remaining rows
+// with the options set to cmp.
+func (cmp *Compare) MergeReader(mode MergeMode) (*MergeReader, error) {
+	def, err := MergeDefinition(cmp.t1.GetDefinition(), cmp.t2.GetDefinition())
+	if err != nil {
+		return nil, err
+	}
+	return &MergeReader{
+		cmp:  cmp,
+		mode: mode,
+		def:  def,
+	}, nil
+}
+
+// GetDefinition returns the merged Definition.
+func (mr *MergeReader) GetDefinition() *Definition {
+	return mr.def
+}
+
+// ReadRow reads one merged record.
+func (mr *MergeReader) ReadRow() ([]string, error) {
 	for {
-		dd, err := diff.ReadDiffRow()
+		dd, err := mr.cmp.ReadDiffRow()
 		if err != nil {
-			if err == io.EOF {
-				return tb, nil
-			}
 			return nil, err
 		}
-		row := dd.MergeRow(mode)
-		if row != nil {
-			tb.RowNum++
-			tb.Rows = append(tb.Rows, row)
+		if row := dd.MergeRow(mr.mode); row != nil {
+			return row, nil
 		}
 	}
 }
 
 // MergeDefinition merges two tbln Definitions.
+// The columns are aligned by name in the order of t1d,
+// and the different types and primary keys are returned as errors.
+// The extras of t2d take precedence, except for the empty columns.
+// t1d and t2d are not changed.
 func MergeDefinition(t1d, t2d *Definition) (*Definition, error) {
 	if t1d.columnNum != t2d.columnNum {
 		return nil, fmt.Errorf("different column num")
 	}
-	d := NewDefinition()
+	pos, err := alignColumns(t1d, t2d)
+	if err != nil {
+		return nil, err
+	}
+	d := t1d.Clone()
+	d.Hashes = make(map[string][]byte)
+	d.Signs = make(Signatures)
 	d.Comments = mergeComment(t1d, t2d)
-	d.Extras = t1d.Extras
-	maps.Copy(d.Extras, t2d.Extras)
+	// The merkle root of the merged rows is different.
+	delete(d.Extras, MerkleRootKey)
+	if len(d.names) == 0 && len(t2d.names) > 0 {
+		d.names = slices.Clone(t2d.names)
+		d.Extras["name"] = t2d.Extras["name"]
+	}
+	if d.tableName == "" && t2d.tableName != "" {
+		d.tableName = t2d.tableName
+		d.Extras["TableName"] = t2d.Extras["TableName"]
+	}
+
+	var errs []error
+	types := make([]string, d.columnNum)
+	for i, p := range pos {
+		typ1, typ2 := columnValue(t1d.types, i), columnValue(t2d.types, p)
+		switch {
+		case typ1 == "":
+			types[i] = typ2
+		case typ2 == "" || typ1 == typ2:
+			types[i] = typ1
+		default:
+			errs = append(errs, fmt.Errorf("column %s: different type %s and %s", columnValue(d.names, i), typ1, typ2))
+		}
+	}
+	if len(t1d.types) > 0 || len(t2d.types) > 0 {
+		d.types = types
+		d.Extras["type"] = NewExtra(JoinRow(types), d.Extras["type"].hashTarget)
+	}
+
+	pk1, pk2 := t1d.extraRow("primarykey"), t2d.extraRow("primarykey")
+	if len(pk1) == 0 && len(pk2) > 0 {
+		d.Extras["primarykey"] = t2d.Extras["primarykey"]
+	} else if len(pk2) > 0 && !slices.Equal(pk1, pk2) {
+		errs = append(errs, fmt.Errorf("different primary key: %s and %s", JoinRow(pk1), JoinRow(pk2)))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	for k, e := range t2d.Extras {
+		switch k {
+		case "name", "type", "primarykey", "TableName", MerkleRootKey:
+			continue
+		}
+		row2 := columnExtra(t2d, k)
+		if row2 == nil {
+			d.Extras[k] = e
+			continue
+		}
+		row := columnExtra(t1d, k)
+		if row == nil {
+			row = make([]string, d.columnNum)
+		}
+		for i, p := range pos {
+			if row2[p] != "" {
+				row[i] = row2[p]
+			}
+		}
+		d.Extras[k] = NewExtra(JoinRow(row), e.hashTarget)
+	}
 	return d, nil
 }
 
+// alignColumns returns the positions in t2d of the columns of t1d.
+// If either has no column names, the columns are in the same order.
+func alignColumns(t1d, t2d *Definition) ([]int, error) {
+	pos := make([]int, t1d.columnNum)
+	for i := range pos {
+		pos[i] = i
+	}
+	if len(t1d.names) == 0 || len(t2d.names) == 0 {
+		return pos, nil
+	}
+	var missing []string
+	for i, name := range t1d.names {
+		p := slices.Index(t2d.names, name)
+		if p < 0 {
+			missing = append(missing, name)
+			continue
+		}
+		pos[i] = p
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("different column names: %s", JoinRow(missing))
+	}
+	return pos, nil
+}
+
+// columnExtra returns the extra that has a value for each column,
+// or nil if the extra is not a column extra.
+func columnExtra(d *Definition, key string) []string {
+	ext, ok := d.Extras[key]
+	if !ok {
+		return nil
+	}
+	value, ok := ext.Value().(string)
+	if !ok || !strings.HasPrefix(value, "| ") {
+		return nil
+	}
+	row := SplitRow(value)
+	if len(row) != d.columnNum {
+		return nil
+	}
+	return row
+}
+
+func columnValue(row []string, i int) string {
+	if i < len(row) {
+		return row[i]
+	}
+	return ""
+}
+
 func mergeComment(t1d, t2d *Definition) []string {
 	comments := make([]string, len(t1d.Comments))
 	copy(comments, t1d.Comments)
@@ -96,3 +262,115 @@ func mergeComment(t1d, t2d *Definition) []string {
 	}
 	return comments
 }
+
+// MergeResolver returns the merged row of the rows that have
+// the same primary key and different values.
+// def is the Definition of self.
+// If the returned row is nil, the row is removed.
+type MergeResolver func(self, other []string, def *Definition) ([]string, error)
+
+// MergeResolution represents how a conflicting row was resolved.
+type MergeResolution struct {
+	Key   []string
+	Self  []string
+	Other []string
+	Row   []string
+}
+
+// MergeReport is the list of the resolved conflicting rows.
+type MergeReport struct {
+	Resolutions []MergeResolution
+}
+
+// MergeAllFunc merges two tbln and returns one tbln.
+// Rows only in either are added,
+// and rows with different values are merged by resolve.
+func MergeAllFunc(t1, t2 Reader, resolve MergeResolver) (*TBLN, *MergeReport, error) {
+	cmp, err := NewCompare(t1, t2)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cmp.MergeAllFunc(resolve)
+}
+
+// MergeAllFunc merges the remaining rows as MergeAllFunc does,
+// with the options set to cmp.
+func (cmp *Compare) MergeAllFunc(resolve MergeResolver) (*TBLN, *MergeReport, error) {
+	t1d := cmp.t1.GetDefinition()
+	tb := &TBLN{}
+	var err error
+	tb.Definition, err = MergeDefinition(t1d, cmp.t2.GetDefinition())
+	if err != nil {
+		return nil, nil, err
+	}
+	tb.Rows = make([][]string, 0)
+	report := &MergeReport{}
+	for {
+		dd, err := cmp.ReadDiffRow()
+		if err != nil {
+			if err == io.EOF {
+				return tb, report, nil
+			}
+			return nil, nil, err
+		}
+		row := dd.MergeRow(MergeIgnore)
+		if dd.Les == 2 {
+			row, err = resolve(dd.Self, dd.Other, t1d)
+			if err != nil {
+				return nil, nil, err
+			}
+			report.Resolutions = append(report.Resolutions, MergeResolution{
+				Key:   ColumnPrimaryKey(cmp.PK, dd.Self),
+				Self:  dd.Self,
+				Other: dd.Other,
+				Row:   row,
+			})
+		}
+		if row != nil {
+			tb.RowNum++
+			tb.Rows = append(tb.Rows, row)
+		}
+	}
+}
+
+// ResolveError is a MergeResolver that returns an error on conflict.
+func ResolveError(self, other []string, def *Definition) ([]string, error) {
+	return nil, fmt.Errorf("merge conflict: %s %s", JoinRow(self), JoinRow(other))
+}
+
+// ResolveNonEmpty is a MergeResolver that merges each column.
+// The column of other is used unless it is empty.
+func ResolveNonEmpty(self, other []string, def *Definition) ([]string, error) {
+	row := make([]string, len(other))
+	for i, v := range other {
+		if v == "" && i < len(self) {
+			v = self[i]
+		}
+		row[i] = v
+	}
+	return row, nil
+}
+
+// ResolveNewest returns a MergeResolver that uses the row
+// whose column has the newer timestamp.
+// If the timestamps are the same, the row of other is used.
+func ResolveNewest(column string) MergeResolver {
+	return func(self, other []string, def *Definition) ([]string, error) {
+		pos, err := def.columnPos([]string{column})
+		if err != nil {
+			return nil, err
+		}
+		t1, err := parseTime(self[pos[0]])
+		if err != nil {
+			return nil, err
+		}
+		t2, err := parseTime(other[pos[0]])
+		if err != nil {
+			return nil, err
+		}
+		if t1.After(t2) {
+			return self, nil
+		}
+		return other, nil
+	}
+}
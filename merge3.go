@@ -0,0 +1,295 @@
+package tbln
+
+import (
+	"fmt"
+	"io"
+	"slices"
+)
+
+// Conflict markers written by WriteMerge3.
+const (
+	merge3Ours   = "<<<<<<< ours"
+	merge3Base   = "||||||| base"
+	merge3Sep    = "======="
+	merge3Theirs = ">>>>>>> theirs"
+)
+
+// Merge3 represents a three-way merge of base, ours and theirs.
+// All three must be sorted by the primary key.
+type Merge3 struct {
+	cmp       *Compare
+	theirs    Reader
+	theirsRow []string
+	// theirsReuse is true if the rows of theirs must be copied to be kept.
+	theirsReuse bool
+	diff        *DiffRow
+	readDiff    bool
+	readTheir   bool
+
+	PK []Pkey
+}
+
+// Merge3Row represents the merge result of one primary key.
+// Row is the merged row, or nil if the row is deleted.
+// If Reason is not empty, the row is a conflict and
+// Row is the row of ours with the non-conflicting changes of theirs.
+// Conflict is the positions of the conflicting columns.
+type Merge3Row struct {
+	Base     []string
+	Ours     []string
+	Theirs   []string
+	Row      []string
+	Conflict []int
+	Reason   string
+}
+
+// IsConflict returns true if the row could not be merged.
+func (m *Merge3Row) IsConflict() bool {
+	return m.Reason != ""
+}
+
+// NewMerge3 returns a new Merge3.
+// Ours and theirs must have the same columns, types
+// and primary key as base.
+func NewMerge3(base, ours, theirs Reader) (*Merge3, error) {
+	cmp, err := NewCompare(base, ours)
+	if err != nil {
+		return nil, err
+	}
+	if err := sameColumns(base.GetDefinition(), ours.GetDefinition()); err != nil {
+		return nil, fmt.Errorf("%w: base and ours", err)
+	}
+	m := &Merge3{
+		cmp:      cmp,
+		theirs:   theirs,
+		readDiff: true,
+		PK:       cmp.PK,
+	}
+	m.theirsRow, err = theirs.ReadRow()
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	m.theirsReuse = reusesRecord(theirs)
+	m.theirsRow = keepRow(m.theirsReuse, m.theirsRow)
+	if err := sameColumns(base.GetDefinition(), theirs.GetDefinition()); err != nil {
+		return nil, fmt.Errorf("%w: base and theirs", err)
+	}
+	return m, nil
+}
+
+// sameColumns returns an error if the names, types or
+// primary key of d2 differ from d1.
+func sameColumns(d1, d2 *Definition) error {
+	switch {
+	case !slices.Equal(d1.Names(), d2.Names()):
+		return fmt.Errorf("different columns")
+	case !slices.Equal(d1.Types(), d2.Types()):
+		return fmt.Errorf("different types")
+	case !slices.Equal(d1.extraRow("primarykey"), d2.extraRow("primarykey")):
+		return fmt.Errorf("different primary key")
+	}
+	return nil
+}
+
+// ReadMerge3Row reads the merge result of the next primary key.
+func (m *Merge3) ReadMerge3Row() (*Merge3Row, error) {
+	var err error
+	if m.readDiff {
+		m.diff, err = m.cmp.ReadDiffRow()
+		if err != nil {
+			if err != io.EOF {
+				return nil, err
+			}
+			m.diff = nil
+		}
+	}
+	if m.readTheir {
+		m.theirsRow, err = m.cmp.readNext(m.theirs, nil, m.theirsRow, m.theirsReuse)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var base, ours, theirs []string
+	switch m.comparePK() {
+	case 0:
+		m.readDiff, m.readTheir = true, true
+		base, ours, theirs = m.diff.Self, m.diff.Other, m.theirsRow
+	case -1:
+		m.readDiff, m.readTheir = true, false
+		base, ours = m.diff.Self, m.diff.Other
+	case 1:
+		m.readDiff, m.readTheir = false, true
+		if len(m.theirsRow) == 0 {
+			return nil, io.EOF
+		}
+		theirs = m.theirsRow
+	}
+	return merge3Row(base, ours, theirs), nil
+}
+
+// comparePK compares the primary key of the current DiffRow and theirs.
+func (m *Merge3) comparePK() int {
+	if m.diff == nil {
+		return 1
+	}
+	if len(m.theirsRow) == 0 {
+		return -1
+	}
+	row := m.diff.Self
+	if row == nil {
+		row = m.diff.Other
+	}
+	return m.cmp.compareKey(row, m.theirsRow)
+}
+
+// merge3Row merges the rows of one primary key.
+func merge3Row(base, ours, theirs []string) *Merge3Row {
+	m := &Merge3Row{Base: base, Ours: ours, Theirs: theirs}
+	switch {
+	case ours == nil && theirs == nil:
+		// Deleted by both.
+	case base == nil && ours == nil:
+		m.Row = theirs
+	case base == nil && theirs == nil:
+		m.Row = ours
+	case base == nil:
+		m.Row = ours
+		if m.Conflict = diffColumns(ours, theirs); len(m.Conflict) > 0 {
+			m.Reason = "both added"
+		}
+	case ours == nil:
+		if !slices.Equal(base, theirs) {
+			m.Reason = "deleted by ours"
+		}
+	case theirs == nil:
+		if !slices.Equal(base, ours) {
+			m.Row = ours
+			m.Reason = "deleted by theirs"
+		}
+	default:
+		m.Row = make([]string, len(ours))
+		for i := range ours {
+			switch {
+			case ours[i] == theirs[i], theirs[i] == base[i]:
+				m.Row[i] = ours[i]
+			case ours[i] == base[i]:
+				m.Row[i] = theirs[i]
+			default:
+				m.Row[i] = ours[i]
+				m.Conflict = append(m.Conflict, i)
+			}
+		}
+		if len(m.Conflict) > 0 {
+			m.Reason = "both modified"
+		}
+	}
+	return m
+}
+
+// diffColumns returns the positions of the columns that differ.
+func diffColumns(row1, row2 []string) []int {
+	var pos []int
+	for i := range row1 {
+		if row1[i] != row2[i] {
+			pos = append(pos, i)
+		}
+	}
+	return pos
+}
+
+// Merge3All merges base, ours and theirs and returns one tbln
+// and the conflicting rows.
+// The conflicting rows are merged into ours.
+func Merge3All(base, ours, theirs Reader) (*TBLN, []*Merge3Row, error) {
+	m, err := NewMerge3(base, ours, theirs)
+	if err != nil {
+		return nil, nil, err
+	}
+	tb := &TBLN{}
+	tb.Definition, err = MergeDefinition(ours.GetDefinition(), theirs.GetDefinition())
+	if err != nil {
+		return nil, nil, err
+	}
+	tb.Rows = make([][]string, 0)
+	var conflicts []*Merge3Row
+	for {
+		mr, err := m.ReadMerge3Row()
+		if err != nil {
+			if err == io.EOF {
+				return tb, conflicts, nil
+			}
+			return nil, nil, err
+		}
+		if mr.IsConflict() {
+			conflicts = append(conflicts, mr)
+		}
+		if mr.Row != nil {
+			tb.RowNum++
+			tb.Rows = append(tb.Rows, mr.Row)
+		}
+	}
+}
+
+// WriteMerge3 writes the merge result of base, ours and theirs to writer,
+// and returns the number of conflicts.
+// The conflicting rows are written with conflict markers.
+func WriteMerge3(writer io.Writer, base, ours, theirs Reader) (int, error) {
+	m, err := NewMerge3(base, ours, theirs)
+	if err != nil {
+		return 0, err
+	}
+	d, err := MergeDefinition(ours.GetDefinition(), theirs.GetDefinition())
+	if err != nil {
+		return 0, err
+	}
+	w := NewWriter(writer)
+	if err := w.WriteDefinition(d); err != nil {
+		return 0, err
+	}
+	conflicts := 0
+	for {
+		mr, err := m.ReadMerge3Row()
+		if err != nil {
+			if err == io.EOF {
+				return conflicts, nil
+			}
+			return conflicts, err
+		}
+		if !mr.IsConflict() {
+			if mr.Row != nil {
+				if err := w.WriteRow(mr.Row); err != nil {
+					return conflicts, err
+				}
+			}
+			continue
+		}
+		conflicts++
+		if err := writeMerge3Conflict(writer, mr); err != nil {
+			return conflicts, err
+		}
+	}
+}
+
+func writeMerge3Conflict(writer io.Writer, mr *Merge3Row) error {
+	lines := []struct {
+		marker string
+		row    []string
+	}{
+		{merge3Ours, mr.Ours},
+		{merge3Base, mr.Base},
+		{merge3Sep, mr.Theirs},
+	}
+	for _, l := range lines {
+		if _, err := io.WriteString(writer, l.marker+"\n"); err != nil {
+			return err
+		}
+		if l.row != nil {
+			if _, err := io.WriteString(writer, JoinRow(l.row)+"\n"); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := io.WriteString(writer, merge3Theirs+"\n")
+	return err
+}
@@ -0,0 +1,214 @@
+package tbln
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+var TestMerge3Base = `; name: | id | name | age |
+; type: | int | text | int |
+; primarykey: | id |
+; TableName: test1
+| 1 | Bob | 19 |
+| 2 | Alice | 14 |
+| 3 | Henry | 19 |
+| 4 | Carol | 30 |
+| 5 | Dave | 41 |
+`
+
+var TestMerge3Ours = `; name: | id | name | age |
+; type: | int | text | int |
+; primarykey: | id |
+; TableName: test1
+| 1 | Bob | 20 |
+| 2 | Alice | 15 |
+| 3 | Henry | 19 |
+| 5 | Dave | 41 |
+| 6 | Eve | 22 |
+`
+
+var TestMerge3Theirs = `; name: | id | name | age |
+; type: | int | text | int |
+; primarykey: | id |
+; TableName: test1
+| 1 | Robert | 19 |
+| 2 | Alice | 16 |
+| 4 | Carol | 30 |
+| 7 | Frank | 33 |
+`
+
+func TestMerge3All(t *testing.T) {
+	tb, conflicts, err := Merge3All(
+		NewReader(bytes.NewBufferString(TestMerge3Base)),
+		NewReader(bytes.NewBufferString(TestMerge3Ours)),
+		NewReader(bytes.NewBufferString(TestMerge3Theirs)),
+	)
+	if err != nil {
+		t.Fatalf("Merge3All() error = %v", err)
+	}
+	wantRows := [][]string{
+		{"1", "Robert", "20"},
+		{"2", "Alice", "15"},
+		{"6", "Eve", "22"},
+		{"7", "Frank", "33"},
+	}
+	if !reflect.DeepEqual(tb.Rows, wantRows) {
+		t.Errorf("Merge3All() = %v, want %v", tb.Rows, wantRows)
+	}
+	wantConflicts := []*Merge3Row{
+		{
+			Base:     []string{"2", "Alice", "14"},
+			Ours:     []string{"2", "Alice", "15"},
+			Theirs:   []string{"2", "Alice", "16"},
+			Row:      []string{"2", "Alice", "15"},
+			Conflict: []int{2},
+			Reason:   "both modified",
+		},
+	}
+	if !reflect.DeepEqual(conflicts, wantConflicts) {
+		t.Errorf("Merge3All() conflicts = %v, want %v", conflicts, wantConflicts)
+	}
+}
+
+func Test_merge3Row(t *testing.T) {
+	base := []string{"1", "Bob", "19"}
+	tests := []struct {
+		name       string
+		base       []string
+		ours       []string
+		theirs     []string
+		wantRow    []string
+		wantReason string
+	}{
+		{name: "same", base: base, ours: base, theirs: base, wantRow: base},
+		{name: "cell", base: base, ours: []string{"1", "Bob", "20"}, theirs: []string{"1", "Robert", "19"}, wantRow: []string{"1", "Robert", "20"}},
+		{name: "sameChange", base: base, ours: []string{"1", "Bob", "20"}, theirs: []string{"1", "Bob", "20"}, wantRow: []string{"1", "Bob", "20"}},
+		{name: "bothModified", base: base, ours: []string{"1", "Bob", "20"}, theirs: []string{"1", "Bob", "21"}, wantRow: []string{"1", "Bob", "20"}, wantReason: "both modified"},
+		{name: "deleteBoth", base: base},
+		{name: "deleteOurs", base: base, theirs: base},
+		{name: "deleteTheirs", base: base, ours: base},
+		{name: "deletedByOurs", base: base, theirs: []string{"1", "Bob", "20"}, wantReason: "deleted by ours"},
+		{name: "deletedByTheirs", base: base, ours: []string{"1", "Bob", "20"}, wantRow: []string{"1", "Bob", "20"}, wantReason: "deleted by theirs"},
+		{name: "addOurs", ours: base, wantRow: base},
+		{name: "addTheirs", theirs: base, wantRow: base},
+		{name: "addSame", ours: base, theirs: base, wantRow: base},
+		{name: "bothAdded", ours: base, theirs: []string{"1", "Bob", "20"}, wantRow: base, wantReason: "both added"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := merge3Row(tt.base, tt.ours, tt.theirs)
+			if !reflect.DeepEqual(got.Row, tt.wantRow) {
+				t.Errorf("merge3Row() = %v, want %v", got.Row, tt.wantRow)
+			}
+			if got.Reason != tt.wantReason {
+				t.Errorf("merge3Row() reason = %v, want %v", got.Reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestWriteMerge3(t *testing.T) {
+	var buf bytes.Buffer
+	n, err := WriteMerge3(&buf,
+		NewReader(bytes.NewBufferString(TestMerge3Base)),
+		NewReader(bytes.NewBufferString(TestMerge3Ours)),
+		NewReader(bytes.NewBufferString(TestMerge3Theirs)),
+	)
+	if err != nil {
+		t.Fatalf("WriteMerge3() error = %v", err)
+	}
+	if n != 1 {
+		t.Errorf("WriteMerge3() conflicts = %d, want 1", n)
+	}
+	want := `; TableName: test1
+; name: | id | name | age |
+; primarykey: | id |
+; type: | int | text | int |
+| 1 | Robert | 20 |
+<<<<<<< ours
+| 2 | Alice | 15 |
+||||||| base
+| 2 | Alice | 14 |
+=======
+| 2 | Alice | 16 |
+>>>>>>> theirs
+| 6 | Eve | 22 |
+| 7 | Frank | 33 |
+`
+	if got := buf.String(); got != want {
+		t.Errorf("WriteMerge3() = \n%v, want \n%v", got, want)
+	}
+}
+
+func TestNewMerge3_Columns(t *testing.T) {
+	tests := []struct {
+		name    string
+		ours    string
+		theirs  string
+		wantErr string
+	}{
+		{
+			name: "order",
+			theirs: `; name: | id | age | name |
+; type: | int | int | text |
+; primarykey: | id |
+| 1 | 19 | Bob |
+`,
+			wantErr: "different columns: base and theirs",
+		},
+		{
+			name: "type",
+			theirs: `; name: | id | name | age |
+; type: | int | text | text |
+; primarykey: | id |
+| 1 | Bob | 19 |
+`,
+			wantErr: "different types: base and theirs",
+		},
+		{
+			name: "primarykey",
+			theirs: `; name: | id | name | age |
+; type: | int | text | int |
+; primarykey: | name |
+| 1 | Bob | 19 |
+`,
+			wantErr: "different primary key: base and theirs",
+		},
+		{
+			name: "oursType",
+			ours: `; name: | id | name | age |
+; type: | int | text | text |
+; primarykey: | id |
+| 1 | Bob | 19 |
+`,
+			wantErr: "different types: base and ours",
+		},
+		{
+			name: "oursPrimarykey",
+			ours: `; name: | id | name | age |
+; type: | int | text | int |
+| 1 | Bob | 19 |
+`,
+			wantErr: "different primary key: base and ours",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.ours == "" {
+				tt.ours = TestMerge3Ours
+			}
+			if tt.theirs == "" {
+				tt.theirs = TestMerge3Theirs
+			}
+			_, err := NewMerge3(
+				NewReader(bytes.NewBufferString(TestMerge3Base)),
+				NewReader(bytes.NewBufferString(tt.ours)),
+				NewReader(bytes.NewBufferString(tt.theirs)),
+			)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("NewMerge3() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
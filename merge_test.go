@@ -65,3 +65,201 @@ func TestMergeAll(t *testing.T) {
 		})
 	}
 }
+
+var TestMergeFunc1 = `; name: | id | name | age | updated |
+; type: | int | text | int | timestamp |
+; primarykey: | id |
+; TableName: test1
+| 1 | Bob | 19 | 2019-01-01 00:00:00 |
+| 2 | Alice |  | 2019-03-01 00:00:00 |
+| 3 | Henry | 19 | 2019-01-01 00:00:00 |
+`
+
+var TestMergeFunc2 = `; name: | id | name | age | updated |
+; type: | int | text | int | timestamp |
+; primarykey: | id |
+; TableName: test1
+| 1 | Robert | 20 | 2019-02-01 00:00:00 |
+| 2 | Alicia | 14 | 2019-02-01 00:00:00 |
+| 4 | Carol | 30 | 2019-01-01 00:00:00 |
+`
+
+func TestMergeAllFunc(t *testing.T) {
+	tests := []struct {
+		name     string
+		resolve  MergeResolver
+		want     [][]string
+		resolved int
+		wantErr  bool
+	}{
+		{
+			name:    "newest",
+			resolve: ResolveNewest("updated"),
+			want: [][]string{
+				{"1", "Robert", "20", "2019-02-01 00:00:00"},
+				{"2", "Alice", "", "2019-03-01 00:00:00"},
+				{"3", "Henry", "19", "2019-01-01 00:00:00"},
+				{"4", "Carol", "30", "2019-01-01 00:00:00"},
+			},
+			resolved: 2,
+		},
+		{
+			name:    "nonEmpty",
+			resolve: ResolveNonEmpty,
+			want: [][]string{
+				{"1", "Robert", "20", "2019-02-01 00:00:00"},
+				{"2", "Alicia", "14", "2019-02-01 00:00:00"},
+				{"3", "Henry", "19", "2019-01-01 00:00:00"},
+				{"4", "Carol", "30", "2019-01-01 00:00:00"},
+			},
+			resolved: 2,
+		},
+		{
+			name:    "newestNoColumn",
+			resolve: ResolveNewest("none"),
+			wantErr: true,
+		},
+		{
+			name:    "error",
+			resolve: ResolveError,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, report, err := MergeAllFunc(NewReader(bytes.NewBufferString(TestMergeFunc1)), NewReader(bytes.NewBufferString(TestMergeFunc2)), tt.resolve)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("MergeAllFunc() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got.Rows, tt.want) {
+				t.Errorf("MergeAllFunc() = %v, want %v", got.Rows, tt.want)
+			}
+			if len(report.Resolutions) != tt.resolved {
+				t.Errorf("MergeAllFunc() resolved = %v, want %v", len(report.Resolutions), tt.resolved)
+			}
+			if r := report.Resolutions[0]; !reflect.DeepEqual(r.Key, []string{"1"}) || !reflect.DeepEqual(r.Row, got.Rows[0]) {
+				t.Errorf("MergeAllFunc() resolution = %v", r)
+			}
+		})
+	}
+}
+
+func TestMergeDefinition(t *testing.T) {
+	def := func(s string) *Definition {
+		tb, err := ReadAll(bytes.NewBufferString(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tb.Definition
+	}
+	t1 := `; name: | id | name | age |
+; type: | int | text | int |
+; primarykey: | id |
+; mysql_columntype: | int(11) | varchar(40) |  |
+; created_at: 2019-01-01
+| 1 | Bob | 19 |
+`
+	tests := []struct {
+		name      string
+		t2        string
+		wantTypes []string
+		wantExtra map[string]string
+		wantErr   bool
+	}{
+		{
+			name: "reorder",
+			t2: `; name: | age | id | name |
+; type: | int |  | text |
+; TableName: test1
+; mysql_columntype: | smallint | bigint |  |
+; created_at: 2019-02-01
+| 19 | 1 | Bob |
+`,
+			wantTypes: []string{"int", "text", "int"},
+			wantExtra: map[string]string{
+				"TableName":        "test1",
+				"primarykey":       "| id |",
+				"mysql_columntype": "| bigint | varchar(40) | smallint |",
+				"created_at":       "2019-02-01",
+			},
+		},
+		{
+			name: "type",
+			t2: `; name: | id | name | age |
+; type: | text | text | numeric |
+| 1 | Bob | 19 |
+`,
+			wantErr: true,
+		},
+		{
+			name: "primarykey",
+			t2: `; name: | id | name | age |
+; primarykey: | name |
+| 1 | Bob | 19 |
+`,
+			wantErr: true,
+		},
+		{
+			name: "name",
+			t2: `; name: | id | name | old |
+| 1 | Bob | 19 |
+`,
+			wantErr: true,
+		},
+		{
+			name: "columnNum",
+			t2: `| 1 | Bob |
+`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t1d := def(t1)
+			orig := t1d.Clone()
+			got, err := MergeDefinition(t1d, def(tt.t2))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("MergeDefinition() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(t1d, orig) {
+				t.Errorf("MergeDefinition() changed t1d = %v, want %v", t1d, orig)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got.Types(), tt.wantTypes) {
+				t.Errorf("MergeDefinition() types = %v, want %v", got.Types(), tt.wantTypes)
+			}
+			for k, v := range tt.wantExtra {
+				if got.ExtraValue(k) != v {
+					t.Errorf("MergeDefinition() %s = %v, want %v", k, got.ExtraValue(k), v)
+				}
+			}
+			if got.TableName() != "test1" {
+				t.Errorf("MergeDefinition() TableName = %v, want test1", got.TableName())
+			}
+		})
+	}
+}
+
+func TestWriteMerge(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteMerge(&buf, NewReader(bytes.NewBufferString(TestSet1)), NewReader(bytes.NewBufferString(TestSet2)), MergeUpdate); err != nil {
+		t.Fatalf("WriteMerge() error = %v", err)
+	}
+	want := `; TableName: test1
+; name: | id | name | age |
+; primarykey: | id |
+; type: | int | text | int |
+| 1 | Bob | 19 |
+| 2 | Alice | 15 |
+| 3 | Henry | 19 |
+| 4 | Carol | 30 |
+`
+	if got := buf.String(); got != want {
+		t.Errorf("WriteMerge() = \n%v, want \n%v", got, want)
+	}
+}
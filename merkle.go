@@ -0,0 +1,330 @@
+package tbln
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MerkleRootKey is the extra name that stores the root of the merkle tree.
+// The value is written as | hashType | number of rows | root |.
+const MerkleRootKey = "merkle_root"
+
+// Prefixes that distinguish leaf and node hashes.
+const (
+	merkleLeaf = 0x00
+	merkleNode = 0x01
+)
+
+// MerkleTree is a hash tree over the rows of TBLN.
+// levels[0] is the leaf hashes and the last level is the root.
+type MerkleTree struct {
+	hashType string
+	levels   [][][]byte
+}
+
+// MerkleProof is the path from a row to the root of the merkle tree.
+type MerkleProof struct {
+	Index  int
+	RowNum int
+	Path   []MerkleNode
+}
+
+// MerkleNode is a sibling hash in the proof path.
+// Left is true if the sibling is on the left side.
+type MerkleNode struct {
+	Hash []byte
+	Left bool
+}
+
+// RowRange represents a range of rows [Start, End).
+type RowRange struct {
+	Start int
+	End   int
+}
+
+// NewMerkleTree returns a merkle tree of rows.
+func NewMerkleTree(hashType string, rows [][]string) (*MerkleTree, error) {
+	if _, err := newHash(hashType); err != nil {
+		return nil, err
+	}
+	mt := &MerkleTree{hashType: hashType}
+	leaves := make([][]byte, len(rows))
+	for i, row := range rows {
+		leaves[i] = mt.leafHash(row)
+	}
+	mt.levels = append(mt.levels, leaves)
+	for level := leaves; len(level) > 1; {
+		next := make([][]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				// The odd node is promoted as it is.
+				next = append(next, level[i])
+				continue
+			}
+			next = append(next, mt.nodeHash(level[i], level[i+1]))
+		}
+		mt.levels = append(mt.levels, next)
+		level = next
+	}
+	return mt, nil
+}
+
+func (mt *MerkleTree) leafHash(row []string) []byte {
+	h, _ := newHash(mt.hashType)
+	h.Write([]byte{merkleLeaf})
+	h.Write([]byte(JoinRow(row)))
+	return h.Sum(nil)
+}
+
+func (mt *MerkleTree) nodeHash(left, right []byte) []byte {
+	h, _ := newHash(mt.hashType)
+	h.Write([]byte{merkleNode})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// RowNum returns the number of rows in the tree.
+func (mt *MerkleTree) RowNum() int {
+	return len(mt.levels[0])
+}
+
+// Root returns the root hash of the tree.
+func (mt *MerkleTree) Root() []byte {
+	top := mt.levels[len(mt.levels)-1]
+	if len(top) == 0 {
+		h, _ := newHash(mt.hashType)
+		return h.Sum(nil)
+	}
+	return top[0]
+}
+
+// Proof returns the proof that the row of index belongs to the tree.
+func (mt *MerkleTree) Proof(index int) (*MerkleProof, error) {
+	if index < 0 || index >= mt.RowNum() {
+		return nil, fmt.Errorf("out of range row %d", index)
+	}
+	proof := &MerkleProof{Index: index, RowNum: mt.RowNum()}
+	pos := index
+	for _, level := range mt.levels[:len(mt.levels)-1] {
+		sibling := pos ^ 1
+		if sibling < len(level) {
+			proof.Path = append(proof.Path, MerkleNode{Hash: level[sibling], Left: sibling < pos})
+		}
+		pos /= 2
+	}
+	return proof, nil
+}
+
+// Verify returns the boolean value of whether the row belongs to root.
+// The root does not include the number of rows, so check proof.RowNum
+// against the trusted number of rows, as Definition.VerifyRow does.
+func (mt *MerkleTree) Verify(root []byte, row []string, proof *MerkleProof) bool {
+	if proof == nil || proof.Index < 0 || proof.Index >= proof.RowNum {
+		return false
+	}
+	h := mt.leafHash(row)
+	path := proof.Path
+	// The side of each sibling is derived from Index and RowNum,
+	// and the odd node of a level is promoted without a sibling.
+	for pos, n := proof.Index, proof.RowNum; n > 1; pos, n = pos/2, (n+1)/2 {
+		sibling := pos ^ 1
+		if sibling >= n {
+			continue
+		}
+		if len(path) == 0 || path[0].Left != (sibling < pos) {
+			return false
+		}
+		if path[0].Left {
+			h = mt.nodeHash(path[0].Hash, h)
+		} else {
+			h = mt.nodeHash(h, path[0].Hash)
+		}
+		path = path[1:]
+	}
+	return len(path) == 0 && bytes.Equal(h, root)
+}
+
+// Diff returns the ranges of rows that differ between the two trees.
+func (mt *MerkleTree) Diff(other *MerkleTree) []RowRange {
+	if mt.RowNum() != other.RowNum() {
+		return coalesceRange(mt.diffLeaves(other))
+	}
+	var rows []int
+	mt.diffNode(other, len(mt.levels)-1, 0, &rows)
+	return coalesceRange(rows)
+}
+
+// diffNode descends only into the nodes whose hashes differ.
+func (mt *MerkleTree) diffNode(other *MerkleTree, level int, pos int, rows *[]int) {
+	if pos >= len(mt.levels[level]) {
+		return
+	}
+	if bytes.Equal(mt.levels[level][pos], other.levels[level][pos]) {
+		return
+	}
+	if level == 0 {
+		*rows = append(*rows, pos)
+		return
+	}
+	mt.diffNode(other, level-1, pos*2, rows)
+	mt.diffNode(other, level-1, pos*2+1, rows)
+}
+
+func (mt *MerkleTree) diffLeaves(other *MerkleTree) []int {
+	self, oth := mt.levels[0], other.levels[0]
+	rows := make([]int, 0)
+	for i := 0; i < max(len(self), len(oth)); i++ {
+		if i >= len(self) || i >= len(oth) || !bytes.Equal(self[i], oth[i]) {
+			rows = append(rows, i)
+		}
+	}
+	return rows
+}
+
+func coalesceRange(rows []int) []RowRange {
+	ranges := make([]RowRange, 0)
+	for _, r := range rows {
+		if n := len(ranges); n > 0 && ranges[n-1].End == r {
+			ranges[n-1].End = r + 1
+			continue
+		}
+		ranges = append(ranges, RowRange{Start: r, End: r + 1})
+	}
+	return ranges
+}
+
+// String returns the proof as a row string.
+func (p *MerkleProof) String() string {
+	row := make([]string, 0, len(p.Path)+2)
+	row = append(row, strconv.Itoa(p.Index), strconv.Itoa(p.RowNum))
+	for _, node := range p.Path {
+		side := "R"
+		if node.Left {
+			side = "L"
+		}
+		row = append(row, side+":"+hex.EncodeToString(node.Hash))
+	}
+	return JoinRow(row)
+}
+
+// ParseMerkleProof parses the proof string returned by MerkleProof.String.
+func ParseMerkleProof(str string) (*MerkleProof, error) {
+	row := SplitRow(str)
+	if len(row) < 2 {
+		return nil, fmt.Errorf("not analyze merkle proof")
+	}
+	var err error
+	p := &MerkleProof{}
+	if p.Index, err = strconv.Atoi(row[0]); err != nil {
+		return nil, err
+	}
+	if p.RowNum, err = strconv.Atoi(row[1]); err != nil {
+		return nil, err
+	}
+	for _, col := range row[2:] {
+		side, h, ok := strings.Cut(col, ":")
+		if !ok || (side != "L" && side != "R") {
+			return nil, fmt.Errorf("not analyze merkle proof: %s", col)
+		}
+		b, err := hex.DecodeString(h)
+		if err != nil {
+			return nil, err
+		}
+		p.Path = append(p.Path, MerkleNode{Hash: b, Left: side == "L"})
+	}
+	return p, nil
+}
+
+// SumMerkle calculates the merkle root of rows and stores it in the extra.
+// The root is a target of the hash, so call SumHash and Sign after this.
+func (t *TBLN) SumMerkle(hashType string) error {
+	mt, err := NewMerkleTree(hashType, t.Rows)
+	if err != nil {
+		return err
+	}
+	t.Extras[MerkleRootKey] = NewExtra(JoinRow([]string{hashType, strconv.Itoa(mt.RowNum()), hex.EncodeToString(mt.Root())}), true)
+	return nil
+}
+
+// MerkleTree returns the merkle tree of rows with the hash type of the stored root.
+func (t *TBLN) MerkleTree() (*MerkleTree, error) {
+	hashType, _, _, err := t.MerkleRoot()
+	if err != nil {
+		return nil, err
+	}
+	return NewMerkleTree(hashType, t.Rows)
+}
+
+// MerkleProof returns the proof of the row of index.
+func (t *TBLN) MerkleProof(index int) (*MerkleProof, error) {
+	mt, err := t.MerkleTree()
+	if err != nil {
+		return nil, err
+	}
+	return mt.Proof(index)
+}
+
+// VerifyMerkle returns the boolean value of the stored root verification.
+func (t *TBLN) VerifyMerkle() bool {
+	_, rowNum, root, err := t.MerkleRoot()
+	if err != nil {
+		return false
+	}
+	mt, err := t.MerkleTree()
+	if err != nil {
+		return false
+	}
+	return mt.RowNum() == rowNum && bytes.Equal(mt.Root(), root)
+}
+
+// MerkleRoot returns the hash type, the number of rows and the root stored in the extra.
+func (d *Definition) MerkleRoot() (string, int, []byte, error) {
+	ext, ok := d.Extras[MerkleRootKey]
+	if !ok {
+		return "", 0, nil, fmt.Errorf("no merkle root")
+	}
+	v := SplitRow(fmt.Sprintf("%s", ext.Value()))
+	if len(v) != 3 {
+		return "", 0, nil, fmt.Errorf("not analyze merkle root")
+	}
+	rowNum, err := strconv.Atoi(v[1])
+	if err != nil {
+		return "", 0, nil, err
+	}
+	root, err := hex.DecodeString(v[2])
+	if err != nil {
+		return "", 0, nil, err
+	}
+	return v[0], rowNum, root, nil
+}
+
+// VerifyRow returns the boolean value of whether the row belongs to the stored root.
+// Rows can be verified without all the rows of the table.
+// The proof must be for the number of rows stored with the root.
+func (d *Definition) VerifyRow(row []string, proof *MerkleProof) bool {
+	hashType, rowNum, root, err := d.MerkleRoot()
+	if err != nil || proof == nil || proof.RowNum != rowNum {
+		return false
+	}
+	mt, err := NewMerkleTree(hashType, nil)
+	if err != nil {
+		return false
+	}
+	return mt.Verify(root, row, proof)
+}
+
+// VerifyRootSignature returns the boolean value of the signature verification
+// of the merkle root. Unlike VerifySignature, the rows are not required.
+func (d *Definition) VerifyRootSignature(name string, pubkey []byte) bool {
+	if d == nil || len(d.Signs) == 0 {
+		return false
+	}
+	if _, _, _, err := d.MerkleRoot(); err != nil {
+		return false
+	}
+	return verifySign(d.Signs[name], pubkey, d.SerializeHash())
+}
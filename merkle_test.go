@@ -0,0 +1,191 @@
+package tbln
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+func merkleRowsHelper(n int) [][]string {
+	rows := make([][]string, n)
+	for i := range n {
+		rows[i] = []string{fmt.Sprint(i), fmt.Sprintf("name%d", i)}
+	}
+	return rows
+}
+
+func TestMerkleTree_Proof(t *testing.T) {
+	for _, n := range []int{1, 2, 3, 5, 8, 13} {
+		t.Run(fmt.Sprintf("rows%d", n), func(t *testing.T) {
+			rows := merkleRowsHelper(n)
+			mt, err := NewMerkleTree(SHA256, rows)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, row := range rows {
+				proof, err := mt.Proof(i)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !mt.Verify(mt.Root(), row, proof) {
+					t.Errorf("MerkleTree.Verify() row %d = false, want true", i)
+				}
+				if mt.Verify(mt.Root(), []string{"x", "y"}, proof) {
+					t.Errorf("MerkleTree.Verify() wrong row %d = true, want false", i)
+				}
+				parsed, err := ParseMerkleProof(proof.String())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(parsed, proof) {
+					t.Errorf("ParseMerkleProof() = %v, want %v", parsed, proof)
+				}
+			}
+		})
+	}
+}
+
+func TestMerkleTree_VerifyMismatch(t *testing.T) {
+	rows := merkleRowsHelper(5)
+	mt, err := NewMerkleTree(SHA256, rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := mt.Proof(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		modify func(p *MerkleProof)
+	}{
+		{name: "index", modify: func(p *MerkleProof) { p.Index = 0 }},
+		{name: "rowNum", modify: func(p *MerkleProof) { p.RowNum = 2 }},
+		{name: "left", modify: func(p *MerkleProof) { p.Path[0].Left = !p.Path[0].Left }},
+		{name: "extra", modify: func(p *MerkleProof) { p.Path = append(p.Path, p.Path[0]) }},
+		{name: "short", modify: func(p *MerkleProof) { p.Path = p.Path[:1] }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &MerkleProof{Index: proof.Index, RowNum: proof.RowNum, Path: slices.Clone(proof.Path)}
+			tt.modify(p)
+			if mt.Verify(mt.Root(), rows[1], p) {
+				t.Errorf("MerkleTree.Verify() = true, want false")
+			}
+		})
+	}
+}
+
+func TestMerkleTree_Diff(t *testing.T) {
+	tests := []struct {
+		name   string
+		n      int
+		change []int
+		add    int
+		want   []RowRange
+	}{
+		{name: "same", n: 8, want: []RowRange{}},
+		{name: "one", n: 8, change: []int{3}, want: []RowRange{{Start: 3, End: 4}}},
+		{name: "range", n: 13, change: []int{4, 5, 6, 12}, want: []RowRange{{Start: 4, End: 7}, {Start: 12, End: 13}}},
+		{name: "append", n: 5, add: 2, want: []RowRange{{Start: 5, End: 7}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orig, err := NewMerkleTree(SHA256, merkleRowsHelper(tt.n))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rows := merkleRowsHelper(tt.n + tt.add)
+			for _, c := range tt.change {
+				rows[c][1] = "tampered"
+			}
+			mt, err := NewMerkleTree(SHA256, rows)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := orig.Diff(mt); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MerkleTree.Diff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTBLN_SumMerkle(t *testing.T) {
+	tb := NewTBLN()
+	if err := tb.SetNames([]string{"id", "name"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range merkleRowsHelper(5) {
+		if err := tb.AddRows(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tb.SumMerkle(SHA256); err != nil {
+		t.Fatal(err)
+	}
+	if err := tb.SumHash(SHA256); err != nil {
+		t.Fatal(err)
+	}
+	pkey := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{1}, ed25519.SeedSize))
+	pubkey := pkey.Public().(ed25519.PublicKey)
+	if _, err := tb.Sign("test", pkey); err != nil {
+		t.Fatal(err)
+	}
+	if !tb.VerifyMerkle() || !tb.VerifySignature("test", pubkey) {
+		t.Fatalf("TBLN.SumMerkle() verification failed")
+	}
+	proof, err := tb.MerkleProof(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the definition and one row are shared.
+	var buf bytes.Buffer
+	if err := NewWriter(&buf).WriteDefinition(tb.Definition); err != nil {
+		t.Fatal(err)
+	}
+	excerpt, err := ReadAll(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !excerpt.VerifyRootSignature("test", pubkey) {
+		t.Errorf("Definition.VerifyRootSignature() = false, want true")
+	}
+	if !excerpt.VerifyRow(tb.Rows[2], proof) {
+		t.Errorf("Definition.VerifyRow() = false, want true")
+	}
+	if excerpt.VerifyRow([]string{"2", "tampered"}, proof) {
+		t.Errorf("Definition.VerifyRow() tampered = true, want false")
+	}
+
+	// The proof of the last row also fits the tree of two rows.
+	last, err := tb.MerkleProof(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := &MerkleProof{Index: 1, RowNum: 2, Path: last.Path}
+	if !excerpt.VerifyRow(tb.Rows[4], last) {
+		t.Errorf("Definition.VerifyRow() last = false, want true")
+	}
+	if excerpt.VerifyRow(tb.Rows[4], forged) {
+		t.Errorf("Definition.VerifyRow() forged RowNum = true, want false")
+	}
+	hashType, _, root, err := excerpt.MerkleRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	excerpt.Extras[MerkleRootKey] = NewExtra(JoinRow([]string{hashType, "2", hex.EncodeToString(root)}), true)
+	if excerpt.VerifyRootSignature("test", pubkey) {
+		t.Errorf("Definition.VerifyRootSignature() forged row number = true, want false")
+	}
+
+	tb.Rows[4][1] = "tampered"
+	if tb.VerifyMerkle() {
+		t.Errorf("TBLN.VerifyMerkle() tampered = true, want false")
+	}
+}
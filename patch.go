@@ -0,0 +1,351 @@
+package tbln
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// PatchOp represents the operation of the patch.
+type PatchOp int
+
+// Represents the patch operation
+const (
+	PatchInsert PatchOp = iota
+	PatchUpdate
+	PatchDelete
+)
+
+func (op PatchOp) String() string {
+	switch op {
+	case PatchInsert:
+		return "insert"
+	case PatchUpdate:
+		return "update"
+	case PatchDelete:
+		return "delete"
+	default:
+		return "Unknown"
+	}
+}
+
+// Line prefixes of the patch format.
+//
+//	+| 3 | Carol |   insert
+//	<| 1 | Bob |     old values of the next update or delete (optional)
+//	>| 1 | Henry |   update
+//	-| 2 | Alice |   delete
+const (
+	patchInsert = "+"
+	patchOld    = "<"
+	patchUpdate = ">"
+	patchDelete = "-"
+)
+
+// PatchRow is one operation keyed by the primary key.
+// Old is the row before the change, and is used for conflict detection.
+// If Old is nil, only the primary key is checked.
+type PatchRow struct {
+	Op  PatchOp
+	Row []string
+	Old []string
+}
+
+// Patch represents the changes from one table to another.
+// Rows are sorted by the primary key.
+type Patch struct {
+	*Definition
+	PK   []Pkey
+	Rows []PatchRow
+}
+
+// PatchConflict represents the patch operation that could not be applied.
+// Current is the row of the source, or nil if there is no row.
+type PatchConflict struct {
+	PatchRow
+	Current []string
+	Reason  string
+}
+
+// NewPatch returns the patch that changes t1 to t2.
+// t1 and t2 must have the same columns and types.
+// If withOld is true, the old values are recorded for conflict detection.
+func NewPatch(t1, t2 Reader, withOld bool) (*Patch, error) {
+	cmp, err := NewCompare(t1, t2)
+	if err != nil {
+		return nil, err
+	}
+	if cmp.t1Pos != nil {
+		return nil, fmt.Errorf("different columns")
+	}
+	if !slices.Equal(t1.GetDefinition().Types(), t2.GetDefinition().Types()) {
+		return nil, fmt.Errorf("different types")
+	}
+	p := &Patch{
+		Definition: t2.GetDefinition().Clone(),
+		PK:         cmp.PK,
+		Rows:       make([]PatchRow, 0),
+	}
+	p.Hashes = make(map[string][]byte)
+	p.Signs = make(Signatures)
+	pkNames := make([]string, 0, len(cmp.PK))
+	for _, pk := range cmp.PK {
+		pkNames = append(pkNames, pk.Name)
+	}
+	p.SetExtra("primarykey", JoinRow(pkNames))
+	for {
+		dd, err := cmp.ReadDiffRow()
+		if err != nil {
+			if err == io.EOF {
+				return p, nil
+			}
+			return nil, err
+		}
+		var old []string
+		if withOld {
+			old = dd.Self
+		}
+		switch dd.Les {
+		case 1:
+			p.Rows = append(p.Rows, PatchRow{Op: PatchInsert, Row: dd.Other})
+		case -1:
+			p.Rows = append(p.Rows, PatchRow{Op: PatchDelete, Row: dd.Self, Old: old})
+		case 2:
+			p.Rows = append(p.Rows, PatchRow{Op: PatchUpdate, Row: dd.Other, Old: old})
+		}
+	}
+}
+
+// WritePatch writes the patch to writer.
+func WritePatch(writer io.Writer, p *Patch) error {
+	w := NewWriter(writer)
+	if err := w.WriteDefinition(p.Definition); err != nil {
+		return err
+	}
+	for _, pr := range p.Rows {
+		if pr.Old != nil {
+			if _, err := io.WriteString(writer, patchOld+JoinRow(pr.Old)+"\n"); err != nil {
+				return err
+			}
+		}
+		var prefix string
+		switch pr.Op {
+		case PatchInsert:
+			prefix = patchInsert
+		case PatchUpdate:
+			prefix = patchUpdate
+		case PatchDelete:
+			prefix = patchDelete
+		default:
+			return fmt.Errorf("unsupported patch operation: %s", pr.Op)
+		}
+		if _, err := io.WriteString(writer, prefix+JoinRow(pr.Row)+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadPatch reads the patch written by WritePatch.
+// ReadPatch returns when the blank line is reached.
+func ReadPatch(r io.Reader) (*Patch, error) {
+	tr := NewReader(r)
+	p := &Patch{Rows: make([]PatchRow, 0)}
+	var old []string
+	for {
+		line, err := tr.readLine()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		if len(line) == 0 {
+			break
+		}
+		str := string(line)
+		var row []string
+		if len(str) > 1 {
+			row = SplitRow(str[1:])
+		}
+		switch {
+		case strings.HasPrefix(str, "#"):
+			tr.Comments = append(tr.Comments, strings.TrimSpace(str[1:]))
+			continue
+		case strings.HasPrefix(str, "; "):
+			if err := tr.analyzeExtra(str); err != nil {
+				return nil, err
+			}
+			continue
+		case strings.HasPrefix(str, patchOld+"| "):
+			old = row
+			continue
+		case strings.HasPrefix(str, patchInsert+"| "):
+			p.Rows = append(p.Rows, PatchRow{Op: PatchInsert, Row: row})
+		case strings.HasPrefix(str, patchUpdate+"| "):
+			p.Rows = append(p.Rows, PatchRow{Op: PatchUpdate, Row: row, Old: old})
+		case strings.HasPrefix(str, patchDelete+"| "):
+			p.Rows = append(p.Rows, PatchRow{Op: PatchDelete, Row: row, Old: old})
+		default:
+			return nil, fmt.Errorf("unsupported line (%s)", str)
+		}
+		if tr.columnNum, err = checkRow(tr.columnNum, row); err != nil {
+			return nil, err
+		}
+		old = nil
+	}
+	p.Definition = tr.Definition
+	var err error
+	p.PK, err = p.keyColumns(nil)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// PatchReader is a Reader that applies a patch to the rows of another Reader.
+// The Reader must have the same columns, types and primary key as the patch,
+// and the rows of both must be sorted by the primary key.
+// Operations that cannot be applied are skipped and recorded in Conflicts.
+type PatchReader struct {
+	r         Reader
+	p         *Patch
+	next      int
+	current   []string
+	prev      []string
+	reuse     bool
+	eof       bool
+	coll      collation
+	init      bool
+	checked   bool
+	Conflicts []PatchConflict
+}
+
+// NewPatchReader returns a new PatchReader that applies p to r.
+func NewPatchReader(r Reader, p *Patch) *PatchReader {
+	return &PatchReader{
+		r: r,
+		p: p,
+	}
+}
+
+// GetDefinition returns the Definition of the patched table.
+func (pr *PatchReader) GetDefinition() *Definition {
+	return pr.p.Definition
+}
+
+// ReadRow reads one patched record.
+func (pr *PatchReader) ReadRow() ([]string, error) {
+	if !pr.init {
+		coll, err := newCollation(pr.p.Collation())
+		if err != nil {
+			return nil, err
+		}
+		pr.coll = coll
+		pr.init = true
+	}
+	for {
+		if pr.current == nil && !pr.eof {
+			row, err := pr.r.ReadRow()
+			if err != nil && err != io.EOF {
+				return nil, err
+			}
+			if !pr.checked {
+				// The Definition of FileReader is complete after reading the first row.
+				if err := pr.checkDefinition(); err != nil {
+					return nil, err
+				}
+				pr.reuse = reusesRecord(pr.r)
+				pr.checked = true
+			}
+			if len(row) == 0 {
+				pr.eof = true
+			} else {
+				if pr.prev != nil && compareKey(pr.p.PK, pr.coll, pr.prev, row) > 0 {
+					return nil, fmt.Errorf("not sorted by primary key: %s", row)
+				}
+				pr.prev = keepRow(pr.reuse, row)
+			}
+			pr.current = row
+		}
+		if pr.next >= len(pr.p.Rows) {
+			if pr.current == nil {
+				return nil, io.EOF
+			}
+			return pr.take(), nil
+		}
+		op := pr.p.Rows[pr.next]
+		c := 1
+		if pr.current != nil {
+			c = compareKey(pr.p.PK, pr.coll, pr.current, op.Row)
+		}
+		if c < 0 {
+			return pr.take(), nil
+		}
+		pr.next++
+		if c > 0 {
+			if op.Op == PatchInsert {
+				return op.Row, nil
+			}
+			pr.conflict(op, nil, "no row")
+			continue
+		}
+		switch op.Op {
+		case PatchInsert:
+			pr.conflict(op, pr.current, "row exists")
+		case PatchUpdate:
+			if op.Old != nil && JoinRow(op.Old) != JoinRow(pr.current) {
+				pr.conflict(op, pr.current, "row changed")
+				continue
+			}
+			pr.current = op.Row
+		case PatchDelete:
+			if op.Old != nil && JoinRow(op.Old) != JoinRow(pr.current) {
+				pr.conflict(op, pr.current, "row changed")
+				continue
+			}
+			pr.current = nil
+		}
+	}
+}
+
+// checkDefinition returns an error if the columns of the source differ from the patch.
+// A source without a primary key is patched by the primary key of the patch.
+func (pr *PatchReader) checkDefinition() error {
+	d := pr.r.GetDefinition()
+	switch {
+	case !slices.Equal(d.Names(), pr.p.Names()):
+		return fmt.Errorf("different columns: source and patch")
+	case !slices.Equal(d.Types(), pr.p.Types()):
+		return fmt.Errorf("different types: source and patch")
+	}
+	if pk := d.extraRow("primarykey"); len(pk) > 0 && !slices.Equal(pk, pr.p.extraRow("primarykey")) {
+		return fmt.Errorf("different primary key: source and patch")
+	}
+	return nil
+}
+
+func (pr *PatchReader) reusesRecord() bool {
+	return reusesRecord(pr.r)
+}
+
+func (pr *PatchReader) take() []string {
+	row := pr.current
+	pr.current = nil
+	return row
+}
+
+func (pr *PatchReader) conflict(op PatchRow, current []string, reason string) {
+	pr.Conflicts = append(pr.Conflicts, PatchConflict{PatchRow: op, Current: current, Reason: reason})
+}
+
+// ApplyPatch writes the rows of r with p applied to writer,
+// and returns the operations that could not be applied.
+func ApplyPatch(writer io.Writer, r Reader, p *Patch) ([]PatchConflict, error) {
+	pr := NewPatchReader(r, p)
+	if err := WriteReader(writer, pr); err != nil {
+		return nil, err
+	}
+	return pr.Conflicts, nil
+}
@@ -0,0 +1,233 @@
+package tbln
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+var TestPatch1 = `; name: | id | name | age |
+; type: | int | text | int |
+; primarykey: | id |
+; TableName: test1
+| 1 | Bob | 19 |
+| 2 | Alice | 14 |
+| 3 | Henry | 19 |
+| 10 | Carol | 30 |
+`
+
+var TestPatch2 = `; name: | id | name | age |
+; type: | int | text | int |
+; primarykey: | id |
+; TableName: test1
+| 1 | Bob | 20 |
+| 3 | Henry | 19 |
+| 4 | Dave | 41 |
+| 10 | Carol | 30 |
+| 11 | Eve | 22 |
+`
+
+var TestPatchOld = `; TableName: test1
+; name: | id | name | age |
+; primarykey: | id |
+; type: | int | text | int |
+<| 1 | Bob | 19 |
+>| 1 | Bob | 20 |
+<| 2 | Alice | 14 |
+-| 2 | Alice | 14 |
++| 4 | Dave | 41 |
++| 11 | Eve | 22 |
+`
+
+func TestNewPatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		withOld bool
+		want    []PatchRow
+	}{
+		{
+			name:    "withOld",
+			withOld: true,
+			want: []PatchRow{
+				{Op: PatchUpdate, Row: []string{"1", "Bob", "20"}, Old: []string{"1", "Bob", "19"}},
+				{Op: PatchDelete, Row: []string{"2", "Alice", "14"}, Old: []string{"2", "Alice", "14"}},
+				{Op: PatchInsert, Row: []string{"4", "Dave", "41"}},
+				{Op: PatchInsert, Row: []string{"11", "Eve", "22"}},
+			},
+		},
+		{
+			name:    "withoutOld",
+			withOld: false,
+			want: []PatchRow{
+				{Op: PatchUpdate, Row: []string{"1", "Bob", "20"}},
+				{Op: PatchDelete, Row: []string{"2", "Alice", "14"}},
+				{Op: PatchInsert, Row: []string{"4", "Dave", "41"}},
+				{Op: PatchInsert, Row: []string{"11", "Eve", "22"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewPatch(NewReader(bytes.NewBufferString(TestPatch1)), NewReader(bytes.NewBufferString(TestPatch2)), tt.withOld)
+			if err != nil {
+				t.Fatalf("NewPatch() error = %v", err)
+			}
+			if !reflect.DeepEqual(p.Rows, tt.want) {
+				t.Errorf("NewPatch() = %v, want %v", p.Rows, tt.want)
+			}
+		})
+	}
+}
+
+func TestWritePatch(t *testing.T) {
+	p, err := NewPatch(NewReader(bytes.NewBufferString(TestPatch1)), NewReader(bytes.NewBufferString(TestPatch2)), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WritePatch(&buf, p); err != nil {
+		t.Fatalf("WritePatch() error = %v", err)
+	}
+	if got := buf.String(); got != TestPatchOld {
+		t.Errorf("WritePatch() = \n%v, want \n%v", got, TestPatchOld)
+	}
+	rp, err := ReadPatch(&buf)
+	if err != nil {
+		t.Fatalf("ReadPatch() error = %v", err)
+	}
+	if !reflect.DeepEqual(rp.Rows, p.Rows) {
+		t.Errorf("ReadPatch() = %v, want %v", rp.Rows, p.Rows)
+	}
+	if !reflect.DeepEqual(rp.PK, p.PK) {
+		t.Errorf("ReadPatch() PK = %v, want %v", rp.PK, p.PK)
+	}
+}
+
+func TestApplyPatch(t *testing.T) {
+	p, err := ReadPatch(bytes.NewBufferString(TestPatchOld))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	conflicts, err := ApplyPatch(&buf, NewReader(bytes.NewBufferString(TestPatch1)), p)
+	if err != nil {
+		t.Fatalf("ApplyPatch() error = %v", err)
+	}
+	if len(conflicts) != 0 {
+		t.Errorf("ApplyPatch() conflicts = %v", conflicts)
+	}
+	got, err := ReadAll(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ReadAll(bytes.NewBufferString(TestPatch2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Rows, want.Rows) {
+		t.Errorf("ApplyPatch() = %v, want %v", got.Rows, want.Rows)
+	}
+}
+
+func TestApplyPatchConflict(t *testing.T) {
+	p, err := ReadPatch(bytes.NewBufferString(TestPatchOld))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Bob is already changed and Eve is already inserted.
+	src := `; name: | id | name | age |
+; type: | int | text | int |
+; primarykey: | id |
+| 1 | Bob | 21 |
+| 3 | Henry | 19 |
+| 11 | Eve | 22 |
+`
+	var buf bytes.Buffer
+	conflicts, err := ApplyPatch(&buf, NewReader(bytes.NewBufferString(src)), p)
+	if err != nil {
+		t.Fatalf("ApplyPatch() error = %v", err)
+	}
+	want := []PatchConflict{
+		{PatchRow: p.Rows[0], Current: []string{"1", "Bob", "21"}, Reason: "row changed"},
+		{PatchRow: p.Rows[1], Current: nil, Reason: "no row"},
+		{PatchRow: p.Rows[3], Current: []string{"11", "Eve", "22"}, Reason: "row exists"},
+	}
+	if !reflect.DeepEqual(conflicts, want) {
+		t.Errorf("ApplyPatch() conflicts = %v, want %v", conflicts, want)
+	}
+	got, err := ReadAll(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRows := [][]string{{"1", "Bob", "21"}, {"3", "Henry", "19"}, {"4", "Dave", "41"}, {"11", "Eve", "22"}}
+	if !reflect.DeepEqual(got.Rows, wantRows) {
+		t.Errorf("ApplyPatch() = %v, want %v", got.Rows, wantRows)
+	}
+}
+
+func TestApplyPatch_Source(t *testing.T) {
+	p, err := ReadPatch(bytes.NewBufferString(TestPatchOld))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		src     string
+		wantErr bool
+	}{
+		{
+			name: "noPrimaryKey",
+			src: `; name: | id | name | age |
+; type: | int | text | int |
+| 1 | Bob | 19 |
+| 2 | Alice | 14 |
+`,
+		},
+		{
+			name: "columnOrder",
+			src: `; name: | name | id | age |
+; type: | text | int | int |
+; primarykey: | id |
+| Bob | 1 | 19 |
+`,
+			wantErr: true,
+		},
+		{
+			name: "type",
+			src: `; name: | id | name | age |
+; type: | int | text | text |
+; primarykey: | id |
+| 1 | Bob | 19 |
+`,
+			wantErr: true,
+		},
+		{
+			name: "primaryKey",
+			src: `; name: | id | name | age |
+; type: | int | text | int |
+; primarykey: | name |
+| 1 | Bob | 19 |
+`,
+			wantErr: true,
+		},
+		{
+			name: "unsorted",
+			src: `; name: | id | name | age |
+; type: | int | text | int |
+; primarykey: | id |
+| 3 | Henry | 19 |
+| 1 | Bob | 19 |
+`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			_, err := ApplyPatch(&buf, NewReader(bytes.NewBufferString(tt.src)), p)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ApplyPatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package tbln
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"slices"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+// KeyStore is a map of key names and public keys.
+type KeyStore map[string][]byte
+
+// ReadKeyStore reads public keys from a keystore in TBLN format.
+// The keystore has the columns keyname, algorithm and publickey(base64).
+func ReadKeyStore(r io.Reader) (KeyStore, error) {
+	at, err := ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(KeyStore)
+	for _, row := range at.Rows {
+		if len(row) != 3 {
+			return nil, fmt.Errorf("keystore format error %s", row)
+		}
+		if row[1] != ED25519 {
+			return nil, fmt.Errorf("not support algorithm: %s", row[1])
+		}
+		pubkey, err := base64.StdEncoding.DecodeString(row[2])
+		if err != nil {
+			return nil, err
+		}
+		if len(pubkey) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("bad public key length: %s", row[0])
+		}
+		keys[row[0]] = pubkey
+	}
+	return keys, nil
+}
+
+// PolicyMode represents the mode of the signature policy.
+type PolicyMode int
+
+// Represents the policy mode
+const (
+	RequireAll PolicyMode = iota
+	RequireAny
+	RequireThreshold
+)
+
+func (m PolicyMode) String() string {
+	switch m {
+	case RequireAll:
+		return "RequireAll"
+	case RequireAny:
+		return "RequireAny"
+	case RequireThreshold:
+		return "RequireThreshold"
+	default:
+		return "Unknown"
+	}
+}
+
+// Policy represents the conditions that signatures must satisfy.
+// Signers is the named set of signers to be verified;
+// a name listed more than once counts as one signer.
+// If Signers is empty, all signatures in the TBLN are verified.
+// Threshold is the number of signers required by RequireThreshold.
+type Policy struct {
+	Mode      PolicyMode
+	Threshold int
+	Signers   []string
+}
+
+// VerifyPolicy verifies the signatures with the public keys of the key store
+// and returns the names of the signers who satisfied the policy.
+// An error is returned if the hash or the policy is not satisfied.
+func (t *TBLN) VerifyPolicy(policy Policy, keys KeyStore) ([]string, error) {
+	if t == nil || t.Definition == nil || len(t.Signs) == 0 {
+		return nil, fmt.Errorf("no signature")
+	}
+	if !t.Verify() {
+		return nil, fmt.Errorf("hash verification failed")
+	}
+	signers := slices.Clone(policy.Signers)
+	if len(signers) == 0 {
+		signers = make([]string, 0, len(t.Signs))
+		for name := range t.Signs {
+			signers = append(signers, name)
+		}
+	}
+	// Duplicate names are counted once.
+	slices.Sort(signers)
+	signers = slices.Compact(signers)
+	hash := t.SerializeHash()
+	verified := make([]string, 0, len(signers))
+	for _, name := range signers {
+		if verifySign(t.Signs[name], keys[name], hash) {
+			verified = append(verified, name)
+		}
+	}
+
+	var need int
+	switch policy.Mode {
+	case RequireAll:
+		need = len(signers)
+	case RequireAny:
+		need = 1
+	case RequireThreshold:
+		if policy.Threshold <= 0 || policy.Threshold > len(signers) {
+			return nil, fmt.Errorf("invalid threshold %d of %d", policy.Threshold, len(signers))
+		}
+		need = policy.Threshold
+	default:
+		return nil, fmt.Errorf("unsupported policy mode: %s", policy.Mode)
+	}
+	if len(verified) < need {
+		return verified, fmt.Errorf("signature policy not satisfied: %s %d of %d", policy.Mode, len(verified), need)
+	}
+	return verified, nil
+}
+
+// verifySign returns the boolean value of one signature verification.
+func verifySign(s Signature, pubkey []byte, hash []byte) bool {
+	if len(pubkey) != ed25519.PublicKeySize {
+		return false
+	}
+	if s.algorithm != ED25519 {
+		return false
+	}
+	return ed25519.Verify(ed25519.PublicKey(pubkey), hash, s.sign)
+}
@@ -0,0 +1,156 @@
+package tbln
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+func signedHelper(t *testing.T, signers ...string) (*TBLN, KeyStore) {
+	t.Helper()
+	tb, err := ReadAll(bytes.NewBufferString(TestData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tb.SumHash(SHA256); err != nil {
+		t.Fatal(err)
+	}
+	keys := make(KeyStore)
+	for i, name := range []string{"alice", "bob", "carol"} {
+		seed := bytes.Repeat([]byte{byte(i + 1)}, ed25519.SeedSize)
+		pkey := ed25519.NewKeyFromSeed(seed)
+		keys[name] = pkey.Public().(ed25519.PublicKey)
+		for _, s := range signers {
+			if s == name {
+				if _, err := tb.Sign(name, pkey); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+	return tb, keys
+}
+
+func TestTBLN_VerifyPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		signers []string
+		policy  Policy
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "testAll",
+			signers: []string{"alice", "bob"},
+			policy:  Policy{Mode: RequireAll},
+			want:    []string{"alice", "bob"},
+			wantErr: false,
+		},
+		{
+			name:    "testAllNamed",
+			signers: []string{"alice", "bob"},
+			policy:  Policy{Mode: RequireAll, Signers: []string{"alice", "bob", "carol"}},
+			want:    []string{"alice", "bob"},
+			wantErr: true,
+		},
+		{
+			name:    "testAny",
+			signers: []string{"carol"},
+			policy:  Policy{Mode: RequireAny, Signers: []string{"alice", "bob", "carol"}},
+			want:    []string{"carol"},
+			wantErr: false,
+		},
+		{
+			name:    "testThreshold",
+			signers: []string{"alice", "carol"},
+			policy:  Policy{Mode: RequireThreshold, Threshold: 2, Signers: []string{"alice", "bob", "carol"}},
+			want:    []string{"alice", "carol"},
+			wantErr: false,
+		},
+		{
+			name:    "testThresholdErr",
+			signers: []string{"bob"},
+			policy:  Policy{Mode: RequireThreshold, Threshold: 2, Signers: []string{"alice", "bob", "carol"}},
+			want:    []string{"bob"},
+			wantErr: true,
+		},
+		{
+			name:    "testThresholdDuplicate",
+			signers: []string{"alice"},
+			policy:  Policy{Mode: RequireThreshold, Threshold: 2, Signers: []string{"alice", "alice", "bob"}},
+			want:    []string{"alice"},
+			wantErr: true,
+		},
+		{
+			name:    "testAllDuplicate",
+			signers: []string{"alice", "bob"},
+			policy:  Policy{Mode: RequireAll, Signers: []string{"bob", "alice", "bob"}},
+			want:    []string{"alice", "bob"},
+			wantErr: false,
+		},
+		{
+			name:    "testInvalidThreshold",
+			signers: []string{"bob"},
+			policy:  Policy{Mode: RequireThreshold, Threshold: 4, Signers: []string{"alice", "bob", "carol"}},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "testNoSign",
+			signers: nil,
+			policy:  Policy{Mode: RequireAny},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb, keys := signedHelper(t, tt.signers...)
+			got, err := tb.VerifyPolicy(tt.policy, keys)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("TBLN.VerifyPolicy() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TBLN.VerifyPolicy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTBLN_VerifyPolicyTampered(t *testing.T) {
+	tb, keys := signedHelper(t, "alice", "bob")
+	tb.Rows[0][1] = "Eve"
+	if _, err := tb.VerifyPolicy(Policy{Mode: RequireAny}, keys); err == nil {
+		t.Errorf("TBLN.VerifyPolicy() tampered table verified")
+	}
+}
+
+func TestReadKeyStore(t *testing.T) {
+	f := openFile(t, filepath.Join("testdata", "keystore.tbln"))
+	defer f.Close()
+	keys, err := ReadKeyStore(f)
+	if err != nil {
+		t.Fatalf("ReadKeyStore() error = %v", err)
+	}
+	want := decode64Helper("7kDELAUpmRy/ZMo7cTNZAnSbGzOlSwxWS31plbl9bO0=")
+	if !reflect.DeepEqual(keys["test"], want) {
+		t.Errorf("ReadKeyStore() = %x, want %x", keys["test"], want)
+	}
+	f2 := openFile(t, filepath.Join("testdata", "abc-s.tbln"))
+	defer f2.Close()
+	tb, err := ReadAll(f2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := tb.VerifyPolicy(Policy{Mode: RequireAll, Signers: []string{"test"}}, keys)
+	if err != nil {
+		t.Errorf("TBLN.VerifyPolicy() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, []string{"test"}) {
+		t.Errorf("TBLN.VerifyPolicy() = %v, want [test]", got)
+	}
+}
@@ -0,0 +1,304 @@
+package tbln
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Extra names of the protection.
+const (
+	// PseudonymizedColumnsKey is the names of the pseudonymized columns.
+	PseudonymizedColumnsKey = "pseudonymized_columns"
+	// EncryptedTypesKey is the original types of the encrypted columns as | name:type |.
+	EncryptedTypesKey = "encrypted_types"
+)
+
+// ProtectMode represents the mode of protection of the columns.
+type ProtectMode int
+
+// Represents the protect mode
+const (
+	// ProtectEncrypt encrypts the cells with a random nonce
+	// and records the NaClSecretBoxKey scheme.
+	// The types of the columns become text until they are decrypted
+	// by NewUnprotectReader with the same key.
+	ProtectEncrypt ProtectMode = iota
+	// ProtectPseudonymize replaces the cells with HMAC-SHA256,
+	// and the types of the columns become text.
+	// The same value is always the same pseudonym,
+	// so Compare can still match rows on masked primary keys.
+	// Pseudonyms do not keep the order of the original values,
+	// so the rows are sorted again when the key columns are masked.
+	ProtectPseudonymize
+)
+
+func (m ProtectMode) String() string {
+	switch m {
+	case ProtectEncrypt:
+		return "ProtectEncrypt"
+	case ProtectPseudonymize:
+		return "ProtectPseudonymize"
+	default:
+		return "Unknown"
+	}
+}
+
+// ProtectReader is a Reader that encrypts, pseudonymizes or decrypts
+// the cells of the specified columns of another Reader.
+// The protected columns are recorded in the extra.
+//
+// When the primary key (or any column without a primary key) is
+// pseudonymized, the rows are returned sorted by the masked key
+// with SortReader, so that Compare can read them.
+// Call Close to remove the temporary files if the rows are not read to the end.
+type ProtectReader struct {
+	r       Reader
+	def     *Definition
+	pos     []int
+	started bool
+	setup   func(d *Definition) ([]int, error)
+	cell    func(string) (string, error)
+	// resort is true if the masked rows may need to be sorted.
+	resort bool
+	sorted Reader
+}
+
+// NewProtectReader returns a new ProtectReader that protects the columns of r with key.
+func NewProtectReader(r Reader, key *[32]byte, mode ProtectMode, columns ...string) *ProtectReader {
+	pr := &ProtectReader{r: r}
+	switch mode {
+	case ProtectPseudonymize:
+		pr.setup = func(d *Definition) ([]int, error) {
+			pos, err := protectSetup(d, PseudonymizedColumnsKey, columns)
+			if err != nil {
+				return nil, err
+			}
+			// Pseudonyms are hex strings whatever the original type is.
+			if _, err := setTextTypes(d, pos); err != nil {
+				return nil, err
+			}
+			return pos, nil
+		}
+		pr.cell = func(s string) (string, error) {
+			return pseudonymize(key, s), nil
+		}
+		pr.resort = true
+	default:
+		pr.setup = func(d *Definition) ([]int, error) {
+			d.Extras[EncryptionKey] = NewExtra(JoinRow([]string{NaClSecretBoxKey, HashCiphertext.String()}), false)
+			pos, err := protectSetup(d, EncryptedColumnsKey, columns)
+			if err != nil {
+				return nil, err
+			}
+			// The ciphertext is base64, so the original types are kept
+			// in the extra and restored by NewUnprotectReader.
+			types, err := setTextTypes(d, pos)
+			if err != nil {
+				return nil, err
+			}
+			for _, t := range d.extraRow(EncryptedTypesKey) {
+				name, _, _ := strings.Cut(t, ":")
+				if !slices.ContainsFunc(types, func(v string) bool { return strings.HasPrefix(v, name+":") }) {
+					types = append(types, t)
+				}
+			}
+			if len(types) > 0 {
+				d.Extras[EncryptedTypesKey] = NewExtra(JoinRow(types), false)
+			}
+			return pos, nil
+		}
+		pr.cell = func(s string) (string, error) {
+			return sealCell(key, s)
+		}
+	}
+	return pr
+}
+
+// NewUnprotectReader returns a new ProtectReader that decrypts
+// the encrypted columns of r with key.
+// Pseudonymized columns remain as they are.
+func NewUnprotectReader(r Reader, key *[32]byte) *ProtectReader {
+	return &ProtectReader{
+		r: r,
+		setup: func(d *Definition) ([]int, error) {
+			if v := d.extraRow(EncryptionKey); len(v) > 0 && v[0] != NaClSecretBoxKey {
+				return nil, fmt.Errorf("not support encryption: %s", v[0])
+			}
+			columns := d.extraRow(EncryptedColumnsKey)
+			pos, err := d.columnPos(columns)
+			if err != nil {
+				return nil, err
+			}
+			if err := restoreTypes(d, d.extraRow(EncryptedTypesKey)); err != nil {
+				return nil, err
+			}
+			delete(d.Extras, EncryptionKey)
+			delete(d.Extras, EncryptedColumnsKey)
+			delete(d.Extras, EncryptedTypesKey)
+			return pos, nil
+		},
+		cell: func(s string) (string, error) {
+			return openCell(key, s)
+		},
+	}
+}
+
+// ReadRow reads one record with the protected columns.
+func (pr *ProtectReader) ReadRow() ([]string, error) {
+	if !pr.resort {
+		return pr.readRow()
+	}
+	if pr.sorted == nil {
+		if err := pr.sortSetup(); err != nil {
+			return nil, err
+		}
+	}
+	return pr.sorted.ReadRow()
+}
+
+// sortSetup reads the first masked row and sorts the rows
+// if the masked columns are the keys of Compare.
+func (pr *ProtectReader) sortSetup() error {
+	inner := &ProtectReader{r: pr.r, setup: pr.setup, cell: pr.cell}
+	u, err := newUnreadReader(inner)
+	if err != nil {
+		return err
+	}
+	d := inner.GetDefinition()
+	pr.def, pr.pos, pr.started = d, inner.pos, true
+	pr.sorted = u
+	keys, err := d.GetPKeyPos()
+	if err != nil || slices.ContainsFunc(inner.pos, func(p int) bool { return slices.Contains(keys, p) }) {
+		pr.sorted = NewSortReader(u)
+	}
+	return nil
+}
+
+// Close removes the temporary files of the sorted rows.
+func (pr *ProtectReader) Close() error {
+	if sr, ok := pr.sorted.(*SortReader); ok {
+		return sr.Close()
+	}
+	return nil
+}
+
+func (pr *ProtectReader) readRow() ([]string, error) {
+	row, err := pr.r.ReadRow()
+	if err != nil {
+		return nil, err
+	}
+	if !pr.started {
+		// The Definition of FileReader is complete after reading the first row.
+		if err := pr.init(); err != nil {
+			return nil, err
+		}
+		pr.started = true
+	}
+	if row == nil {
+		return nil, nil
+	}
+	prow := make([]string, len(row))
+	copy(prow, row)
+	for _, p := range pr.pos {
+		if p >= len(row) {
+			return nil, fmt.Errorf("invalid column num (%d) %s", p, row)
+		}
+		if prow[p], err = pr.cell(row[p]); err != nil {
+			return nil, err
+		}
+	}
+	return prow, nil
+}
+
+// GetDefinition returns the Definition with the protected columns recorded.
+func (pr *ProtectReader) GetDefinition() *Definition {
+	if !pr.started {
+		if err := pr.init(); err != nil {
+			return pr.r.GetDefinition()
+		}
+	}
+	return pr.def
+}
+
+func (pr *ProtectReader) init() error {
+	d := pr.r.GetDefinition().Clone()
+	pos, err := pr.setup(d)
+	if err != nil {
+		return err
+	}
+	// The hashes and signatures are no longer valid.
+	d.Hashes = make(map[string][]byte)
+	d.Signs = make(Signatures)
+	pr.def = d
+	pr.pos = pos
+	return nil
+}
+
+// protectSetup records the columns in the extra of key and returns the positions.
+func protectSetup(d *Definition, key string, columns []string) ([]int, error) {
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no column")
+	}
+	pos, err := d.columnPos(columns)
+	if err != nil {
+		return nil, err
+	}
+	columns = slices.Clone(columns)
+	for _, c := range d.extraRow(key) {
+		if !slices.Contains(columns, c) {
+			columns = append(columns, c)
+		}
+	}
+	d.Extras[key] = NewExtra(JoinRow(columns), false)
+	return pos, nil
+}
+
+// setTextTypes sets the types of the columns at pos to text
+// and returns the original types that are changed as | name:type |.
+func setTextTypes(d *Definition, pos []int) ([]string, error) {
+	types := slices.Clone(d.Types())
+	if len(types) == 0 {
+		return nil, nil
+	}
+	var orig []string
+	for _, p := range pos {
+		if p >= len(types) || types[p] == "text" {
+			continue
+		}
+		orig = append(orig, d.names[p]+":"+types[p])
+		types[p] = "text"
+	}
+	if len(orig) == 0 {
+		return nil, nil
+	}
+	return orig, d.SetTypes(types)
+}
+
+// restoreTypes sets the types of | name:type |.
+func restoreTypes(d *Definition, nameTypes []string) error {
+	if len(nameTypes) == 0 {
+		return nil
+	}
+	types := slices.Clone(d.Types())
+	for _, nt := range nameTypes {
+		name, typ, ok := strings.Cut(nt, ":")
+		p := slices.Index(d.Names(), name)
+		if !ok || p < 0 || p >= len(types) {
+			return fmt.Errorf("invalid %s: %s", EncryptedTypesKey, nt)
+		}
+		types[p] = typ
+	}
+	return d.SetTypes(types)
+}
+
+// pseudonymize returns the deterministic pseudonym of str.
+func pseudonymize(key *[32]byte, str string) string {
+	mac := hmac.New(sha256.New, key[:])
+	_, _ = io.WriteString(mac, str)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,144 @@
+package tbln
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+var TestProtect1 = `; name: | email | name | age |
+; type: | text | text | int |
+; primarykey: | email |
+; TableName: test1
+| alice@example.com | Alice | 14 |
+| bob@example.com | Bob | 19 |
+`
+
+var TestProtect2 = `; name: | email | name | age |
+; type: | text | text | int |
+; primarykey: | email |
+; TableName: test1
+| alice@example.com | Alice | 15 |
+| bob@example.com | Bob | 19 |
+`
+
+func readRowsHelper(t *testing.T, r Reader) [][]string {
+	t.Helper()
+	rows := make([][]string, 0)
+	for {
+		row, err := r.ReadRow()
+		if err != nil {
+			if err == io.EOF {
+				return rows
+			}
+			t.Fatal(err)
+		}
+		rows = append(rows, row)
+	}
+}
+
+func TestProtectReader(t *testing.T) {
+	key := &[32]byte{1, 2, 3}
+	tests := []struct {
+		name      string
+		mode      ProtectMode
+		columns   []string
+		extra     string
+		wantTypes []string
+		wantErr   bool
+	}{
+		{name: "encrypt", mode: ProtectEncrypt, columns: []string{"email", "name"}, extra: EncryptedColumnsKey, wantTypes: []string{"text", "text", "int"}},
+		{name: "encryptInt", mode: ProtectEncrypt, columns: []string{"age"}, extra: EncryptedColumnsKey, wantTypes: []string{"text", "text", "text"}},
+		{name: "pseudonymize", mode: ProtectPseudonymize, columns: []string{"email"}, extra: PseudonymizedColumnsKey, wantTypes: []string{"text", "text", "int"}},
+		{name: "pseudonymizeInt", mode: ProtectPseudonymize, columns: []string{"age"}, extra: PseudonymizedColumnsKey, wantTypes: []string{"text", "text", "text"}},
+		{name: "noColumn", mode: ProtectEncrypt, columns: []string{"phone"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := NewProtectReader(NewReader(bytes.NewBufferString(TestProtect1)), key, tt.mode, tt.columns...)
+			row, err := pr.ReadRow()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ProtectReader.ReadRow() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if reflect.DeepEqual(row, []string{"alice@example.com", "Alice", "14"}) {
+				t.Errorf("ProtectReader.ReadRow() = %v", row)
+			}
+			if got := pr.GetDefinition().extraRow(tt.extra); !reflect.DeepEqual(got, tt.columns) {
+				t.Errorf("ProtectReader.GetDefinition() %s = %v, want %v", tt.extra, got, tt.columns)
+			}
+			if got := pr.GetDefinition().Types(); !reflect.DeepEqual(got, tt.wantTypes) {
+				t.Errorf("ProtectReader.GetDefinition() types = %v, want %v", got, tt.wantTypes)
+			}
+		})
+	}
+}
+
+func TestNewUnprotectReader(t *testing.T) {
+	key := &[32]byte{1, 2, 3}
+	pr := NewProtectReader(NewReader(bytes.NewBufferString(TestProtect1)), key, ProtectEncrypt, "email", "age")
+	pr = NewProtectReader(pr, key, ProtectPseudonymize, "name")
+	tb := NewTBLN()
+	tb.Rows = readRowsHelper(t, pr)
+	tb.RowNum = len(tb.Rows)
+	tb.Definition = pr.GetDefinition()
+	var buf bytes.Buffer
+	if err := WriteAll(&buf, tb); err != nil {
+		t.Fatal(err)
+	}
+
+	ur := NewUnprotectReader(NewReader(&buf), key)
+	got := readRowsHelper(t, ur)
+	if got[0][0] != "alice@example.com" || got[0][1] != pseudonymize(key, "Alice") || got[0][2] != "14" {
+		t.Errorf("UnprotectReader.ReadRow() = %v", got[0])
+	}
+	if ur.GetDefinition().ExtraValue(EncryptedColumnsKey) != nil || ur.GetDefinition().ExtraValue(EncryptedTypesKey) != nil {
+		t.Errorf("UnprotectReader.GetDefinition() has %s", EncryptedColumnsKey)
+	}
+	if types := ur.GetDefinition().Types(); !reflect.DeepEqual(types, []string{"text", "text", "int"}) {
+		t.Errorf("UnprotectReader.GetDefinition() types = %v", types)
+	}
+	if ur.GetDefinition().ExtraValue(PseudonymizedColumnsKey) == nil {
+		t.Errorf("UnprotectReader.GetDefinition() has no %s", PseudonymizedColumnsKey)
+	}
+}
+
+func TestNewUnprotectReader_Scheme(t *testing.T) {
+	key := &[32]byte{1, 2, 3}
+	pr := NewProtectReader(NewReader(bytes.NewBufferString(TestProtect1)), key, ProtectEncrypt, "email")
+	if _, err := pr.ReadRow(); err != nil {
+		t.Fatal(err)
+	}
+	if got := pr.GetDefinition().extraRow(EncryptionKey); len(got) == 0 || got[0] != NaClSecretBoxKey {
+		t.Errorf("ProtectReader.GetDefinition() %s = %v, want %s", EncryptionKey, got, NaClSecretBoxKey)
+	}
+
+	src := `; name: | email | name | age |
+; type: | text | text | int |
+; encryption: | nacl-secretbox | plaintext |
+; encrypted_columns: | email |
+| xxx | Alice | 14 |
+`
+	ur := NewUnprotectReader(NewReader(bytes.NewBufferString(src)), key)
+	if _, err := ur.ReadRow(); err == nil {
+		t.Errorf("UnprotectReader.ReadRow() %s error = nil, want error", NaClSecretBox)
+	}
+}
+
+func TestProtectReader_Diff(t *testing.T) {
+	key := &[32]byte{1, 2, 3}
+	t1 := NewProtectReader(NewReader(bytes.NewBufferString(TestProtect1)), key, ProtectPseudonymize, "email")
+	t2 := NewProtectReader(NewReader(bytes.NewBufferString(TestProtect2)), key, ProtectPseudonymize, "email")
+	var buf bytes.Buffer
+	if err := DiffAll(&buf, t1, t2, OnlyDiff); err != nil {
+		t.Fatalf("DiffAll() error = %v", err)
+	}
+	alice := pseudonymize(key, "alice@example.com")
+	want := "-| " + alice + " | Alice | 14 |\n+| " + alice + " | Alice | 15 |\n"
+	if got := buf.String(); got != want {
+		t.Errorf("DiffAll() = \n%v, want \n%v", got, want)
+	}
+}
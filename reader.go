@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"slices"
 	"strings"
 )
 
@@ -17,7 +18,43 @@ type Reader interface {
 // FileReader reads records from a tbln file.
 type FileReader struct {
 	*Definition
-	r *bufio.Reader
+	// ReuseRecord controls whether calls to ReadRow may return a slice
+	// sharing the backing array of the previous call's returned slice
+	// for performance.
+	// Compare, SortReader, ReadBlock and the *All functions copy the rows they keep,
+	// but other callers must copy a row before the next ReadRow.
+	// The strings of the columns are not reused.
+	ReuseRecord bool
+
+	r      *bufio.Reader
+	buf    []byte
+	record []string
+	field  []byte
+	ends   []int
+}
+
+// recordReuser is implemented by the Readers that may return
+// the same slice from ReadRow, such as FileReader with ReuseRecord.
+type recordReuser interface {
+	reusesRecord() bool
+}
+
+// reusesRecord returns true if the rows of r must be copied to be kept.
+func reusesRecord(r Reader) bool {
+	rr, ok := r.(recordReuser)
+	return ok && rr.reusesRecord()
+}
+
+// keepRow returns row, or a copy of row if reuse is true.
+func keepRow(reuse bool, row []string) []string {
+	if reuse {
+		return slices.Clone(row)
+	}
+	return row
+}
+
+func (tr *FileReader) reusesRecord() bool {
+	return tr.ReuseRecord
 }
 
 // NewReader returns a new Reader that reads from r.
@@ -70,38 +107,180 @@ func ReadAll(r io.Reader) (*TBLN, error) {
 	}
 }
 
-// scanLine reads from tr and returns either one row or a blank line.
-// Comments and Extra lines are read until reaching a row or blank line.
-func (tr *FileReader) scanLine() ([]string, error) {
-	var buf bytes.Buffer
+// readAllRows reads all records of r and returns a tbln struct.
+func readAllRows(r Reader) (*TBLN, error) {
+	reuse := reusesRecord(r)
+	at := &TBLN{}
+	at.Rows = make([][]string, 0)
+	for {
+		rec, err := r.ReadRow()
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if len(rec) == 0 {
+			at.Definition = r.GetDefinition()
+			return at, nil
+		}
+		at.RowNum++
+		at.Rows = append(at.Rows, keepRow(reuse, rec))
+	}
+}
+
+// ReadBlock reads one block up to a blank line and returns a tbln struct.
+// The Definition is reset for each block, so each block can have its own
+// extras, hashes and signatures.
+// ReadBlock returns io.EOF when there are no more blocks.
+func (tr *FileReader) ReadBlock() (*TBLN, error) {
 	for {
-		buf.Reset()
+		tr.Definition = NewDefinition()
+		at := &TBLN{}
+		at.Rows = make([][]string, 0)
+		eof := false
 		for {
-			line, isPrefix, err := tr.r.ReadLine()
+			rec, err := tr.ReadRow()
 			if err != nil {
-				return nil, err
+				if err != io.EOF {
+					return nil, err
+				}
+				eof = true
+				break
 			}
-			buf.Write(line)
-			if !isPrefix {
+			// blank line
+			if rec == nil {
 				break
 			}
+			at.RowNum++
+			at.Rows = append(at.Rows, keepRow(tr.ReuseRecord, rec))
+		}
+		at.Definition = tr.Definition
+		// Skip consecutive blank lines.
+		if at.RowNum > 0 || !at.isEmpty() {
+			return at, nil
+		}
+		if eof {
+			return nil, io.EOF
+		}
+	}
+}
+
+// scanLine reads from tr and returns either one row or a blank line.
+// Comments and Extra lines are read until reaching a row or blank line.
+func (tr *FileReader) scanLine() ([]string, error) {
+	for {
+		line, err := tr.readLine()
+		if err != nil {
+			return nil, err
 		}
-		str := buf.String()
 		switch {
-		case strings.HasPrefix(str, "| "):
-			return SplitRow(str), nil
-		case strings.HasPrefix(str, "#"):
-			tr.Comments = append(tr.Comments, strings.TrimSpace(str[1:]))
-		case strings.HasPrefix(str, "; "):
-			if err := tr.analyzeExtra(str); err != nil {
+		case bytes.HasPrefix(line, rowPrefix):
+			return tr.splitLine(line), nil
+		case bytes.HasPrefix(line, commentPrefix):
+			tr.Comments = append(tr.Comments, strings.TrimSpace(string(line[1:])))
+		case bytes.HasPrefix(line, extraPrefix):
+			if err := tr.analyzeExtra(string(line)); err != nil {
 				return nil, err
 			}
-		case str == "":
+		case len(line) == 0:
 			return nil, nil
 		default:
-			return nil, fmt.Errorf("unsupported line (%s)", str)
+			return nil, fmt.Errorf("unsupported line (%s)", line)
+		}
+	}
+}
+
+var (
+	rowPrefix     = []byte("| ")
+	rowSuffix     = []byte(" |")
+	rowSep        = []byte(" | ")
+	commentPrefix = []byte("#")
+	extraPrefix   = []byte("; ")
+)
+
+// splitLine returns the columns of the row line as SplitRow does.
+// The unescaped columns are converted to one string,
+// so a row is one allocation with ReuseRecord.
+func (tr *FileReader) splitLine(line []byte) []string {
+	if len(line) < 4 {
+		return nil
+	}
+	line = line[len(rowPrefix):]
+	line = bytes.TrimSuffix(line, rowSuffix)
+	tr.field = tr.field[:0]
+	tr.ends = tr.ends[:0]
+	for {
+		i := bytes.Index(line, rowSep)
+		if i < 0 {
+			break
+		}
+		tr.field = appendUnescape(tr.field, line[:i])
+		tr.ends = append(tr.ends, len(tr.field))
+		line = line[i+len(rowSep):]
+	}
+	tr.field = appendUnescape(tr.field, line)
+	tr.ends = append(tr.ends, len(tr.field))
+
+	var rec []string
+	if tr.ReuseRecord {
+		rec = tr.record[:0]
+	} else {
+		rec = make([]string, 0, len(tr.ends))
+	}
+	str := string(tr.field)
+	start := 0
+	for _, end := range tr.ends {
+		rec = append(rec, str[start:end])
+		start = end
+	}
+	if tr.ReuseRecord {
+		tr.record = rec
+	}
+	return rec
+}
+
+// appendUnescape appends src to dst with one vertical bar removed
+// from each sequence of two or more vertical bars, as unescape does.
+func appendUnescape(dst []byte, src []byte) []byte {
+	for len(src) > 0 {
+		i := bytes.IndexByte(src, '|')
+		if i < 0 {
+			return append(dst, src...)
+		}
+		dst = append(dst, src[:i]...)
+		src = src[i:]
+		n := 0
+		for n < len(src) && src[n] == '|' {
+			n++
+		}
+		if n > 1 {
+			dst = append(dst, src[1:n]...)
+		} else {
+			dst = append(dst, '|')
+		}
+		src = src[n:]
+	}
+	return dst
+}
+
+// readLine reads one line without the end of line.
+// The line is valid until the next readLine.
+func (tr *FileReader) readLine() ([]byte, error) {
+	line, isPrefix, err := tr.r.ReadLine()
+	if err != nil {
+		return nil, err
+	}
+	if !isPrefix {
+		return line, nil
+	}
+	// The line is longer than the buffer.
+	tr.buf = append(tr.buf[:0], line...)
+	for isPrefix {
+		line, isPrefix, err = tr.r.ReadLine()
+		if err != nil {
+			return nil, err
 		}
+		tr.buf = append(tr.buf, line...)
 	}
+	return tr.buf, nil
 }
 
 // Analyze Extra.
@@ -3,9 +3,12 @@ package tbln
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"fmt"
 	"io"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -255,6 +258,40 @@ func TestReadAll(t *testing.T) {
 	}
 }
 
+func TestReader_ReadBlock(t *testing.T) {
+	in := `; TableName: block1
+; name: | id |
+| 1 |
+| 2 |
+
+
+# second
+; TableName: block2
+; name: | id | name |
+| 1 | Bob |
+`
+	tr := NewReader(bytes.NewBufferString(in))
+	want := []struct {
+		tableName string
+		rows      [][]string
+	}{
+		{tableName: "block1", rows: [][]string{{"1"}, {"2"}}},
+		{tableName: "block2", rows: [][]string{{"1", "Bob"}}},
+	}
+	for _, w := range want {
+		got, err := tr.ReadBlock()
+		if err != nil {
+			t.Fatalf("Reader.ReadBlock() error = %v", err)
+		}
+		if got.TableName() != w.tableName || !reflect.DeepEqual(got.Rows, w.rows) {
+			t.Errorf("Reader.ReadBlock() = %s %v, want %s %v", got.TableName(), got.Rows, w.tableName, w.rows)
+		}
+	}
+	if _, err := tr.ReadBlock(); err != io.EOF {
+		t.Errorf("Reader.ReadBlock() error = %v, want io.EOF", err)
+	}
+}
+
 func TestReadFile(t *testing.T) {
 	type args struct {
 		reader io.Reader
@@ -316,3 +353,174 @@ func Test_unescape(t *testing.T) {
 		})
 	}
 }
+
+func TestReader_ReuseRecord(t *testing.T) {
+	src := "| 1 | Bob |\n| 2 | Alice |\n"
+	for _, reuse := range []bool{false, true} {
+		tr := NewReader(bytes.NewBufferString(src))
+		tr.ReuseRecord = reuse
+		first, err := tr.ReadRow()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(first, []string{"1", "Bob"}) {
+			t.Errorf("ReadRow() = %v", first)
+		}
+		second, err := tr.ReadRow()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(second, []string{"2", "Alice"}) {
+			t.Errorf("ReadRow() = %v", second)
+		}
+		if shared := &first[0] == &second[0]; shared != reuse {
+			t.Errorf("ReadRow() ReuseRecord %v shared = %v", reuse, shared)
+		}
+	}
+}
+
+func TestReader_ReuseRecordConsumers(t *testing.T) {
+	reuse := func(src string) *FileReader {
+		tr := NewReader(bytes.NewBufferString(src))
+		tr.ReuseRecord = true
+		return tr
+	}
+	var buf bytes.Buffer
+	if err := DiffAll(&buf, reuse(TestUnsorted1), reuse(TestUnsorted2), AllDiff); err == nil {
+		t.Errorf("DiffAll() unsorted error = nil, want error")
+	}
+
+	tb, err := ExceptAll(reuse(TestSet1), reuse(TestSet2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"2", "Alice", "14"}, {"3", "Henry", "19"}}
+	if !reflect.DeepEqual(tb.Rows, want) {
+		t.Errorf("ExceptAll() = %v, want %v", tb.Rows, want)
+	}
+
+	got := readRowsHelper(t, NewSortReader(reuse(TestUnsorted1)))
+	want = [][]string{{"1", "Bob", "19"}, {"2", "Alice", "14"}, {"3", "Henry", "19"}, {"10", "Carol", "30"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SortReader.ReadRow() = %v, want %v", got, want)
+	}
+
+	tb, err = reuse("| 1 | Bob |\n| 2 | Alice |\n").ReadBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = [][]string{{"1", "Bob"}, {"2", "Alice"}}
+	if !reflect.DeepEqual(tb.Rows, want) {
+		t.Errorf("ReadBlock() = %v, want %v", tb.Rows, want)
+	}
+}
+
+func Test_reusesRecord(t *testing.T) {
+	reuse := NewReader(bytes.NewBufferString(TestSet1))
+	reuse.ReuseRecord = true
+	tests := []struct {
+		name string
+		r    Reader
+		want bool
+	}{
+		{name: "FileReader", r: NewReader(bytes.NewBufferString(TestSet1)), want: false},
+		{name: "ReuseRecord", r: reuse, want: true},
+		{name: "ContextReader", r: NewContextReader(context.Background(), reuse), want: true},
+		{name: "SortReader", r: NewSortReader(reuse), want: false},
+		{name: "OwnReader", r: NewOwnReader(NewTBLN()), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := reusesRecord(tt.r); got != tt.want {
+				t.Errorf("reusesRecord() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReader_longLine(t *testing.T) {
+	long := strings.Repeat("a", 10000)
+	tr := NewReader(bytes.NewBufferString("| " + long + " | b |\n| c | d |\n"))
+	got := readRowsHelper(t, tr)
+	want := [][]string{{long, "b"}, {"c", "d"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadRow() long line = %d rows", len(got))
+	}
+}
+
+// readRowRegexp is the previous implementation of FileReader.ReadRow,
+// which copies every line through bytes.Buffer and splits it with regexp.
+func readRowRegexp(tr *FileReader) ([]string, error) {
+	var buf bytes.Buffer
+	for {
+		buf.Reset()
+		for {
+			line, isPrefix, err := tr.r.ReadLine()
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(line)
+			if !isPrefix {
+				break
+			}
+		}
+		str := buf.String()
+		switch {
+		case strings.HasPrefix(str, "| "):
+			return splitRowRegexp(str), nil
+		case strings.HasPrefix(str, "#"):
+			tr.Comments = append(tr.Comments, strings.TrimSpace(str[1:]))
+		case strings.HasPrefix(str, "; "):
+			if err := tr.analyzeExtra(str); err != nil {
+				return nil, err
+			}
+		case str == "":
+			return nil, nil
+		default:
+			return nil, fmt.Errorf("unsupported line (%s)", str)
+		}
+	}
+}
+
+func benchmarkReadRow(b *testing.B, reuse bool) {
+	var buf bytes.Buffer
+	for range 1000 {
+		buf.WriteString(JoinRow(benchRow) + "\n")
+	}
+	data := buf.Bytes()
+	b.ReportAllocs()
+	for b.Loop() {
+		tr := NewReader(bytes.NewReader(data))
+		tr.ReuseRecord = reuse
+		for {
+			if _, err := tr.ReadRow(); err != nil {
+				break
+			}
+		}
+	}
+}
+
+func BenchmarkReader_ReadRowRegexp(b *testing.B) {
+	var buf bytes.Buffer
+	for range 1000 {
+		buf.WriteString(JoinRow(benchRow) + "\n")
+	}
+	data := buf.Bytes()
+	b.ReportAllocs()
+	for b.Loop() {
+		tr := NewReader(bytes.NewReader(data))
+		for {
+			if _, err := readRowRegexp(tr); err != nil {
+				break
+			}
+		}
+	}
+}
+
+func BenchmarkReader_ReadRow(b *testing.B) {
+	benchmarkReadRow(b, false)
+}
+
+func BenchmarkReader_ReadRowReuseRecord(b *testing.B) {
+	benchmarkReadRow(b, true)
+}
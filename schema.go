@@ -0,0 +1,169 @@
+package tbln
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DefinitionDiff represents the difference between two Definitions.
+type DefinitionDiff struct {
+	TableName      *ValueChange  `json:"table_name,omitempty"`
+	AddedColumns   []ColumnDef   `json:"added_columns,omitempty"`
+	RemovedColumns []ColumnDef   `json:"removed_columns,omitempty"`
+	RenamedColumns []ValueChange `json:"renamed_columns,omitempty"`
+	TypeChanges    []TypeChange  `json:"type_changes,omitempty"`
+	PrimaryKey     *ValueChange  `json:"primary_key,omitempty"`
+	Extras         []ExtraChange `json:"extras,omitempty"`
+}
+
+// ValueChange represents the change of a value.
+type ValueChange struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// ColumnDef represents a column name and type.
+type ColumnDef struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// TypeChange represents the change of a column type.
+type TypeChange struct {
+	Name string `json:"name"`
+	Old  string `json:"old"`
+	New  string `json:"new"`
+}
+
+// ExtraChange represents the change of an extra.
+// Column is the column name if the extra has a value for each column.
+type ExtraChange struct {
+	Key    string `json:"key"`
+	Column string `json:"column,omitempty"`
+	Old    string `json:"old"`
+	New    string `json:"new"`
+}
+
+// DiffDefinition returns the difference from d1 to d2.
+// A column that is removed and added at the same position
+// with the same type is treated as renamed.
+func DiffDefinition(d1, d2 *Definition) *DefinitionDiff {
+	dd := &DefinitionDiff{}
+	if d1.TableName() != d2.TableName() {
+		dd.TableName = &ValueChange{Old: d1.TableName(), New: d2.TableName()}
+	}
+	names1, names2 := d1.Names(), d2.Names()
+	renamed := make(map[string]string)
+	renamedTo := make(map[string]bool)
+	for i, name := range names1 {
+		if slices.Contains(names2, name) {
+			continue
+		}
+		typ := columnValue(d1.Types(), i)
+		if i < len(names2) && !slices.Contains(names1, names2[i]) && typ == columnValue(d2.Types(), i) {
+			renamed[name] = names2[i]
+			renamedTo[names2[i]] = true
+			dd.RenamedColumns = append(dd.RenamedColumns, ValueChange{Old: name, New: names2[i]})
+			continue
+		}
+		dd.RemovedColumns = append(dd.RemovedColumns, ColumnDef{Name: name, Type: typ})
+	}
+	for i, name := range names2 {
+		if slices.Contains(names1, name) || renamedTo[name] {
+			continue
+		}
+		dd.AddedColumns = append(dd.AddedColumns, ColumnDef{Name: name, Type: columnValue(d2.Types(), i)})
+	}
+
+	// The position in d2 of the columns of d1.
+	pos := make(map[int]int)
+	for i, name := range names1 {
+		if n, ok := renamed[name]; ok {
+			name = n
+		}
+		if p := slices.Index(names2, name); p >= 0 {
+			pos[i] = p
+		}
+	}
+	for i, name := range names1 {
+		p, ok := pos[i]
+		if !ok {
+			continue
+		}
+		typ1, typ2 := columnValue(d1.Types(), i), columnValue(d2.Types(), p)
+		if typ1 != typ2 {
+			dd.TypeChanges = append(dd.TypeChanges, TypeChange{Name: name, Old: typ1, New: typ2})
+		}
+	}
+
+	pk1, pk2 := d1.extraRow("primarykey"), d2.extraRow("primarykey")
+	if !slices.Equal(pk1, pk2) {
+		dd.PrimaryKey = &ValueChange{Old: JoinRow(pk1), New: JoinRow(pk2)}
+	}
+
+	e1, e2 := unifiedExtras(d1), unifiedExtras(d2)
+	for _, key := range unionKeys(e1, e2) {
+		switch key {
+		case "name", "type", "primarykey", "TableName":
+			continue
+		}
+		row1, row2 := columnExtra(d1, key), columnExtra(d2, key)
+		if row1 != nil && row2 != nil {
+			for i, name := range names1 {
+				if p, ok := pos[i]; ok && row1[i] != row2[p] {
+					dd.Extras = append(dd.Extras, ExtraChange{Key: key, Column: name, Old: row1[i], New: row2[p]})
+				}
+			}
+			continue
+		}
+		if v1, v2 := e1[key], e2[key]; v1 != v2 {
+			dd.Extras = append(dd.Extras, ExtraChange{Key: key, Old: v1, New: v2})
+		}
+	}
+	return dd
+}
+
+// IsEmpty returns true if there is no difference.
+func (dd *DefinitionDiff) IsEmpty() bool {
+	return dd.TableName == nil && len(dd.AddedColumns) == 0 && len(dd.RemovedColumns) == 0 &&
+		len(dd.RenamedColumns) == 0 && len(dd.TypeChanges) == 0 && dd.PrimaryKey == nil && len(dd.Extras) == 0
+}
+
+// IsBreaking returns true if the difference breaks the readers of d1.
+// Added columns and extra changes are not breaking.
+func (dd *DefinitionDiff) IsBreaking() bool {
+	return dd.TableName != nil || len(dd.RemovedColumns) > 0 || len(dd.RenamedColumns) > 0 ||
+		len(dd.TypeChanges) > 0 || dd.PrimaryKey != nil
+}
+
+// String returns the difference as text, one change per line.
+func (dd *DefinitionDiff) String() string {
+	var b strings.Builder
+	if dd.TableName != nil {
+		fmt.Fprintf(&b, "~ TableName: %s -> %s\n", dd.TableName.Old, dd.TableName.New)
+	}
+	for _, c := range dd.RemovedColumns {
+		fmt.Fprintf(&b, "- column: %s %s\n", c.Name, c.Type)
+	}
+	for _, c := range dd.AddedColumns {
+		fmt.Fprintf(&b, "+ column: %s %s\n", c.Name, c.Type)
+	}
+	for _, c := range dd.RenamedColumns {
+		fmt.Fprintf(&b, "~ column: %s -> %s\n", c.Old, c.New)
+	}
+	for _, c := range dd.TypeChanges {
+		fmt.Fprintf(&b, "~ type: %s: %s -> %s\n", c.Name, c.Old, c.New)
+	}
+	if dd.PrimaryKey != nil {
+		fmt.Fprintf(&b, "~ primarykey: %s -> %s\n", dd.PrimaryKey.Old, dd.PrimaryKey.New)
+	}
+	for _, e := range dd.Extras {
+		key := e.Key
+		if e.Column != "" {
+			key += "[" + e.Column + "]"
+		}
+		fmt.Fprintf(&b, "~ %s: %s -> %s\n", key, e.Old, e.New)
+	}
+	return b.String()
+}
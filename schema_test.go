@@ -0,0 +1,63 @@
+package tbln
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+var TestSchema1 = `; name: | id | name | age | note |
+; type: | int | text | int | int |
+; primarykey: | id |
+; TableName: test1
+; postgres_type: | integer | text | integer | integer |
+; comment: users
+| 1 | Bob | 19 | 1 |
+`
+
+var TestSchema2 = `; name: | id | fullname | age | email |
+; type: | bigint | text | int | text |
+; primarykey: | id | fullname |
+; TableName: test2
+; postgres_type: | bigint | text | integer | varchar |
+| 1 | Bob | 19 | bob@example.com |
+`
+
+func TestDiffDefinition(t *testing.T) {
+	def := func(s string) *Definition {
+		tb, err := ReadAll(bytes.NewBufferString(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tb.Definition
+	}
+	dd := DiffDefinition(def(TestSchema1), def(TestSchema2))
+	want := `~ TableName: test1 -> test2
+- column: note int
++ column: email text
+~ column: name -> fullname
+~ type: id: int -> bigint
+~ primarykey: | id | -> | id | fullname |
+~ comment: users -> 
+~ postgres_type[id]: integer -> bigint
+`
+	if got := dd.String(); got != want {
+		t.Errorf("DiffDefinition() = \n%v, want \n%v", got, want)
+	}
+	if !dd.IsBreaking() {
+		t.Errorf("DefinitionDiff.IsBreaking() = false, want true")
+	}
+	b, err := json.Marshal(dd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantJSON := `{"table_name":{"old":"test1","new":"test2"},"added_columns":[{"name":"email","type":"text"}],"removed_columns":[{"name":"note","type":"int"}],"renamed_columns":[{"old":"name","new":"fullname"}],"type_changes":[{"name":"id","old":"int","new":"bigint"}],"primary_key":{"old":"| id |","new":"| id | fullname |"},"extras":[{"key":"comment","old":"users","new":""},{"key":"postgres_type","column":"id","old":"integer","new":"bigint"}]}`
+	if string(b) != wantJSON {
+		t.Errorf("DiffDefinition() JSON = %s, want %s", b, wantJSON)
+	}
+
+	same := DiffDefinition(def(TestSchema1), def(TestSchema1))
+	if !same.IsEmpty() || same.IsBreaking() || same.String() != "" {
+		t.Errorf("DiffDefinition() = %v, want empty", same)
+	}
+}
@@ -0,0 +1,149 @@
+package tbln
+
+import (
+	"fmt"
+)
+
+// SetOp represents the set operation of SetReader.
+type SetOp int
+
+// Represents the set operation
+const (
+	SetExcept SetOp = iota
+	SetIntersect
+	SetUnion
+	SetSymmetricDifference
+)
+
+func (op SetOp) String() string {
+	switch op {
+	case SetExcept:
+		return "Except"
+	case SetIntersect:
+		return "Intersect"
+	case SetUnion:
+		return "Union"
+	case SetSymmetricDifference:
+		return "SymmetricDifference"
+	default:
+		return "Unknown"
+	}
+}
+
+// SetReader is a Reader that returns the result of the set operation of two Readers.
+// Rows are compared as a whole, and rows with the same primary key
+// and different values are different rows.
+// Both must be sorted by the primary key.
+//
+// The result of SetExcept and SetIntersect is sorted by the primary key.
+// The result of SetUnion and SetSymmetricDifference can contain rows with
+// the same primary key, so the primary key is removed from the Definition
+// and the result is sorted by all columns, as Compare uses them without
+// a primary key. If the primary key columns are not the leading columns,
+// the result is sorted by SortReader; call Close to remove the temporary
+// files if the rows are not read to the end.
+type SetReader struct {
+	cmp     *Compare
+	op      SetOp
+	def     *Definition
+	all     []Pkey
+	pending []string
+	sorted  *SortReader
+}
+
+// NewSetReader returns a new SetReader that returns t1 op t2.
+func NewSetReader(t1, t2 Reader, op SetOp) (*SetReader, error) {
+	cmp, err := NewCompare(t1, t2)
+	if err != nil {
+		return nil, err
+	}
+	if cmp.t1Pos != nil {
+		return nil, fmt.Errorf("different columns")
+	}
+	def := t1.GetDefinition().Clone()
+	def.Hashes = make(map[string][]byte)
+	def.Signs = make(Signatures)
+	sr := &SetReader{
+		cmp: cmp,
+		op:  op,
+		def: def,
+	}
+	if op != SetUnion && op != SetSymmetricDifference {
+		return sr, nil
+	}
+	delete(def.Extras, "primarykey")
+	sr.all = make([]Pkey, def.ColumnNum())
+	for i := range sr.all {
+		sr.all[i] = Pkey{Pos: i, Name: columnValue(def.Names(), i), Typ: columnValue(def.Types(), i)}
+	}
+	for i, pk := range cmp.PK {
+		if pk.Pos != i {
+			// Sorted by the primary key is not sorted by all columns.
+			return &SetReader{def: def, sorted: NewSortReader(sr)}, nil
+		}
+	}
+	return sr, nil
+}
+
+// Close removes the temporary files of SortReader.
+func (sr *SetReader) Close() error {
+	if sr.sorted != nil {
+		return sr.sorted.Close()
+	}
+	return nil
+}
+
+// GetDefinition returns the Definition of t1
+// without the hashes and signatures.
+func (sr *SetReader) GetDefinition() *Definition {
+	return sr.def
+}
+
+// ReadRow reads one record of the result.
+func (sr *SetReader) ReadRow() ([]string, error) {
+	if sr.sorted != nil {
+		return sr.sorted.ReadRow()
+	}
+	if sr.pending != nil {
+		row := sr.pending
+		sr.pending = nil
+		return row, nil
+	}
+	for {
+		dd, err := sr.cmp.ReadDiffRow()
+		if err != nil {
+			return nil, err
+		}
+		switch sr.op {
+		case SetExcept:
+			if dd.Les == -1 || dd.Les == 2 {
+				return dd.Self, nil
+			}
+		case SetIntersect:
+			if dd.Les == 0 {
+				return dd.Self, nil
+			}
+		case SetUnion, SetSymmetricDifference:
+			switch dd.Les {
+			case 0:
+				if sr.op == SetUnion {
+					return dd.Self, nil
+				}
+			case 1:
+				return dd.Other, nil
+			case -1:
+				return dd.Self, nil
+			case 2:
+				// Both rows have the same primary key.
+				first, second := dd.Self, dd.Other
+				if compareKey(sr.all, sr.cmp.coll, first, second) > 0 {
+					first, second = second, first
+				}
+				sr.pending = second
+				return first, nil
+			}
+		default:
+			return nil, fmt.Errorf("unsupported set operation: %s", sr.op)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package tbln
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+var TestSet1 = `; name: | id | name | age |
+; type: | int | text | int |
+; primarykey: | id |
+; TableName: test1
+| 1 | Bob | 19 |
+| 2 | Alice | 14 |
+| 3 | Henry | 19 |
+`
+
+var TestSet2 = `; name: | id | name | age |
+; type: | int | text | int |
+; primarykey: | id |
+; TableName: test1
+| 1 | Bob | 19 |
+| 2 | Alice | 15 |
+| 4 | Carol | 30 |
+`
+
+func TestSetReader(t *testing.T) {
+	tests := []struct {
+		op     SetOp
+		want   [][]string
+		wantPK []string
+	}{
+		{
+			op:     SetExcept,
+			want:   [][]string{{"2", "Alice", "14"}, {"3", "Henry", "19"}},
+			wantPK: []string{"id"},
+		},
+		{
+			op:     SetIntersect,
+			want:   [][]string{{"1", "Bob", "19"}},
+			wantPK: []string{"id"},
+		},
+		{
+			op:     SetUnion,
+			want:   [][]string{{"1", "Bob", "19"}, {"2", "Alice", "14"}, {"2", "Alice", "15"}, {"3", "Henry", "19"}, {"4", "Carol", "30"}},
+			wantPK: nil,
+		},
+		{
+			op:     SetSymmetricDifference,
+			want:   [][]string{{"2", "Alice", "14"}, {"2", "Alice", "15"}, {"3", "Henry", "19"}, {"4", "Carol", "30"}},
+			wantPK: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.op.String(), func(t *testing.T) {
+			sr, err := NewSetReader(NewReader(bytes.NewBufferString(TestSet1)), NewReader(bytes.NewBufferString(TestSet2)), tt.op)
+			if err != nil {
+				t.Fatalf("NewSetReader() error = %v", err)
+			}
+			got := readRowsHelper(t, sr)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SetReader.ReadRow() = %v, want %v", got, tt.want)
+			}
+			if sr.GetDefinition().TableName() != "test1" {
+				t.Errorf("SetReader.GetDefinition() = %v", sr.GetDefinition())
+			}
+			if pk := sr.GetDefinition().extraRow("primarykey"); !reflect.DeepEqual(pk, tt.wantPK) {
+				t.Errorf("SetReader.GetDefinition() primarykey = %v, want %v", pk, tt.wantPK)
+			}
+		})
+	}
+}
+
+func TestSetReader_Chain(t *testing.T) {
+	// (t1 intersect t2) union t2 is t2.
+	sr, err := NewSetReader(NewReader(bytes.NewBufferString(TestSet1)), NewReader(bytes.NewBufferString(TestSet2)), SetIntersect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ur, err := NewSetReader(sr, NewReader(bytes.NewBufferString(TestSet2)), SetUnion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := readRowsHelper(t, ur)
+	want := [][]string{{"1", "Bob", "19"}, {"2", "Alice", "15"}, {"4", "Carol", "30"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SetReader.ReadRow() = %v, want %v", got, want)
+	}
+}
+
+func TestSetReader_ChainDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		t1   string
+		t2   string
+		want string
+	}{
+		{
+			name: "leadingKey",
+			t1: `; name: | id | name |
+; type: | int | text |
+; primarykey: | id |
+| 1 | Bob |
+| 2 | Carol |
+`,
+			t2: `; name: | id | name |
+; type: | int | text |
+; primarykey: | id |
+| 1 | Alice |
+`,
+			want: `; name: | id | name |
+; type: | int | text |
+| 1 | Alice |
+| 1 | Bob |
+| 2 | Carol |
+`,
+		},
+		{
+			name: "trailingKey",
+			t1: `; name: | name | id |
+; type: | text | int |
+; primarykey: | id |
+| Zed | 1 |
+| Amy | 2 |
+`,
+			t2: `; name: | name | id |
+; type: | text | int |
+; primarykey: | id |
+| Bob | 1 |
+`,
+			want: `; name: | name | id |
+; type: | text | int |
+| Amy | 2 |
+| Bob | 1 |
+| Zed | 1 |
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sr, err := NewSetReader(NewReader(bytes.NewBufferString(tt.t1)), NewReader(bytes.NewBufferString(tt.t2)), SetUnion)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer sr.Close()
+			var buf bytes.Buffer
+			if err := DiffAll(&buf, sr, NewReader(bytes.NewBufferString(tt.want)), OnlyDiff); err != nil {
+				t.Fatalf("DiffAll() error = %v", err)
+			}
+			if buf.String() != "" {
+				t.Errorf("DiffAll() = %v, want no difference", buf.String())
+			}
+		})
+	}
+}
+
+func TestExceptAll(t *testing.T) {
+	tb, err := ExceptAll(NewReader(bytes.NewBufferString(TestSet1)), NewReader(bytes.NewBufferString(TestSet2)))
+	if err != nil {
+		t.Fatalf("ExceptAll() error = %v", err)
+	}
+	want := [][]string{{"2", "Alice", "14"}, {"3", "Henry", "19"}}
+	if !reflect.DeepEqual(tb.Rows, want) || tb.RowNum != 2 {
+		t.Errorf("ExceptAll() = %v, want %v", tb.Rows, want)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteExcept(&buf, NewReader(bytes.NewBufferString(TestSet1)), NewReader(bytes.NewBufferString(TestSet2))); err != nil {
+		t.Fatalf("WriteExcept() error = %v", err)
+	}
+	got, err := ReadAll(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Rows, want) {
+		t.Errorf("WriteExcept() = %v, want %v", got.Rows, want)
+	}
+}
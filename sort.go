@@ -0,0 +1,513 @@
+package tbln
+
+import (
+	"bufio"
+	"container/heap"
+	"hash"
+	"io"
+	"os"
+	"slices"
+)
+
+// DefaultSortRows is the default number of rows sorted in memory.
+const DefaultSortRows = 100000
+
+// DefaultSortFiles is the default number of temporary files merged at once.
+const DefaultSortFiles = 64
+
+// SortReader is a Reader that returns the rows of another Reader
+// sorted by the given columns, the primary key, or all columns.
+// The columns are compared by type as Compare does.
+// The rows are read and sorted on the first ReadRow.
+// When the rows exceed MaxRows, the sorted chunks are spilled to
+// temporary files and merged, so that memory usage is bounded.
+// When the files exceed MaxFiles, they are merged in passes of MaxFiles,
+// so that the number of open files is bounded.
+type SortReader struct {
+	MaxRows  int
+	MaxFiles int
+	// Collation is the collation of the text keys.
+	// If it is empty, the collation extra of the Definition is used.
+	Collation string
+
+	r      Reader
+	coll   collation
+	keys   []string
+	pk     []Pkey
+	sorted bool
+	rows   [][]string
+	next   int
+	files  []string
+	open   []*os.File
+	merge  *sortMerge
+}
+
+// NewSortReader returns a new SortReader that sorts the rows of r.
+// If columns is empty, the rows are sorted by the primary key.
+func NewSortReader(r Reader, columns ...string) *SortReader {
+	return &SortReader{
+		MaxRows:  DefaultSortRows,
+		MaxFiles: DefaultSortFiles,
+		r:        r,
+		keys:     columns,
+	}
+}
+
+// NewSortReaders returns SortReaders that sort t1 and t2 by the same key,
+// as Compare uses the primary key of t1 or t2.
+func NewSortReaders(t1, t2 Reader) (*SortReader, *SortReader, error) {
+	// Read the first rows to complete the Definitions.
+	u1, err := newUnreadReader(t1)
+	if err != nil {
+		return nil, nil, err
+	}
+	u2, err := newUnreadReader(t2)
+	if err != nil {
+		return nil, nil, err
+	}
+	keys := t1.GetDefinition().extraRow("primarykey")
+	if len(keys) == 0 {
+		keys = t2.GetDefinition().extraRow("primarykey")
+	}
+	if len(keys) == 0 {
+		// Without a primary key, Compare uses the common columns
+		// in the order of t1, so both are sorted by them by name.
+		names2 := t2.GetDefinition().Names()
+		for _, name := range t1.GetDefinition().Names() {
+			if slices.Contains(names2, name) {
+				keys = append(keys, name)
+			}
+		}
+	}
+	name, err := commonCollation(t1.GetDefinition(), t2.GetDefinition())
+	if err != nil {
+		return nil, nil, err
+	}
+	s1, s2 := NewSortReader(u1, keys...), NewSortReader(u2, keys...)
+	s1.Collation, s2.Collation = name, name
+	return s1, s2, nil
+}
+
+// NewCompareUnsorted returns a Compare of two readers that are not sorted.
+// Both are sorted by NewSortReaders before comparison.
+// Call Compare.Close to remove the temporary files
+// if the rows are not read to the end.
+func NewCompareUnsorted(t1, t2 Reader) (*Compare, error) {
+	s1, s2, err := NewSortReaders(t1, t2)
+	if err != nil {
+		return nil, err
+	}
+	cmp, err := NewCompare(s1, s2)
+	if err != nil {
+		_ = s1.Close()
+		_ = s2.Close()
+		return nil, err
+	}
+	return cmp, nil
+}
+
+// DiffAllUnsorted is DiffAll for two readers that are not sorted.
+func DiffAllUnsorted(writer io.Writer, t1, t2 Reader, diffMode DiffMode) error {
+	s1, s2, err := NewSortReaders(t1, t2)
+	if err != nil {
+		return err
+	}
+	defer s1.Close()
+	defer s2.Close()
+	return DiffAll(writer, s1, s2, diffMode)
+}
+
+// MergeAllUnsorted is MergeAll for two readers that are not sorted.
+func MergeAllUnsorted(t1, t2 Reader, mode MergeMode) (*TBLN, error) {
+	s1, s2, err := NewSortReaders(t1, t2)
+	if err != nil {
+		return nil, err
+	}
+	defer s1.Close()
+	defer s2.Close()
+	return MergeAll(s1, s2, mode)
+}
+
+// ExceptAllUnsorted is ExceptAll for two readers that are not sorted.
+func ExceptAllUnsorted(t1, t2 Reader) (*TBLN, error) {
+	s1, s2, err := NewSortReaders(t1, t2)
+	if err != nil {
+		return nil, err
+	}
+	defer s1.Close()
+	defer s2.Close()
+	return ExceptAll(s1, s2)
+}
+
+// GetDefinition returns the Definition of the original Reader.
+func (sr *SortReader) GetDefinition() *Definition {
+	return sr.r.GetDefinition()
+}
+
+// ReadRow reads one record in sorted order.
+func (sr *SortReader) ReadRow() ([]string, error) {
+	if !sr.sorted {
+		if err := sr.sort(); err != nil {
+			_ = sr.Close()
+			return nil, err
+		}
+		sr.sorted = true
+	}
+	if sr.merge == nil {
+		if sr.next >= len(sr.rows) {
+			sr.rows = nil
+			return nil, io.EOF
+		}
+		row := sr.rows[sr.next]
+		sr.next++
+		return row, nil
+	}
+	row, err := sr.merge.next()
+	if err == io.EOF {
+		if err := sr.Close(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	return row, err
+}
+
+// Close removes the temporary files.
+// The temporary files are also removed when all rows have been read.
+func (sr *SortReader) Close() error {
+	var err error
+	for _, f := range sr.open {
+		if cerr := f.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	for _, name := range sr.files {
+		if rerr := os.Remove(name); rerr != nil && err == nil {
+			err = rerr
+		}
+	}
+	sr.open = nil
+	sr.files = nil
+	sr.rows = nil
+	sr.merge = &sortMerge{}
+	sr.sorted = true
+	return err
+}
+
+// SortAll writes the rows of sr in sorted order to writer.
+// The Definition is kept and the hashes are recomputed.
+// Signatures and the merkle root are removed because they are no longer valid.
+func SortAll(writer io.Writer, sr *SortReader) error {
+	defer sr.Close()
+	row, err := sr.ReadRow()
+	if err != nil && err != io.EOF {
+		return err
+	}
+	d := sr.GetDefinition().Clone()
+	delete(d.Extras, MerkleRootKey)
+	d.Signs = make(Signatures)
+
+	// The hashes are written before the rows, so the rows are
+	// written to a temporary file while the hashes are calculated.
+	f, err := os.CreateTemp("", "tbln-sort-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	hashes := make(map[string]hash.Hash, len(d.Hashes))
+	writers := []io.Writer{}
+	for hashType := range d.Hashes {
+		h, err := newHash(hashType)
+		if err != nil {
+			return err
+		}
+		if err := NewWriter(h).writeExtraTarget(d, true); err != nil {
+			return err
+		}
+		hashes[hashType] = h
+		writers = append(writers, h)
+	}
+	bw := bufio.NewWriter(f)
+	w := NewWriter(io.MultiWriter(append(writers, bw)...))
+	for len(row) > 0 {
+		if err := w.WriteRow(row); err != nil {
+			return err
+		}
+		row, err = sr.ReadRow()
+		if err != nil && err != io.EOF {
+			return err
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	for hashType, h := range hashes {
+		d.Hashes[hashType] = h.Sum(nil)
+	}
+	if err := NewWriter(writer).WriteDefinition(d); err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	_, err = io.Copy(writer, f)
+	return err
+}
+
+// sort reads all rows, sorts them and spills them if necessary.
+func (sr *SortReader) sort() error {
+	row, err := sr.r.ReadRow()
+	if err != nil && err != io.EOF {
+		return err
+	}
+	// The Definition of FileReader is complete after reading the first row.
+	sr.pk, err = sr.GetDefinition().keyColumns(sr.keys)
+	if err != nil {
+		return err
+	}
+	name := sr.Collation
+	if name == "" {
+		name = sr.GetDefinition().Collation()
+	}
+	if sr.coll, err = newCollation(name); err != nil {
+		return err
+	}
+	reuse := reusesRecord(sr.r)
+	for len(row) > 0 {
+		sr.rows = append(sr.rows, keepRow(reuse, row))
+		if sr.MaxRows > 0 && len(sr.rows) >= sr.MaxRows {
+			if err := sr.spill(); err != nil {
+				return err
+			}
+		}
+		row, err = sr.r.ReadRow()
+		if err != nil && err != io.EOF {
+			return err
+		}
+	}
+	sr.sortRows()
+	if len(sr.files) == 0 {
+		return nil
+	}
+	if err := sr.spill(); err != nil {
+		return err
+	}
+	for sr.MaxFiles > 1 && len(sr.files) > sr.MaxFiles {
+		if err := sr.mergePass(); err != nil {
+			return err
+		}
+	}
+	sr.merge, err = sr.openMerge(sr.files)
+	return err
+}
+
+// openMerge opens the files and returns the merge of them.
+// The opened files are closed by Close.
+func (sr *SortReader) openMerge(names []string) (*sortMerge, error) {
+	m := &sortMerge{pk: sr.pk, coll: sr.coll}
+	for i, name := range names {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		sr.open = append(sr.open, f)
+		r := NewReader(f)
+		row, err := r.ReadRow()
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if len(row) > 0 {
+			heap.Push(m, sortItem{row: row, src: i, r: r})
+		}
+	}
+	return m, nil
+}
+
+// mergePass merges every MaxFiles files into one file.
+// The order of the files is kept, so that the sort is stable.
+func (sr *SortReader) mergePass() error {
+	merged := make([]string, 0, len(sr.files)/sr.MaxFiles+1)
+	for i := 0; i < len(sr.files); i += sr.MaxFiles {
+		names := sr.files[i:min(i+sr.MaxFiles, len(sr.files))]
+		if len(names) == 1 {
+			merged = append(merged, names[0])
+			continue
+		}
+		name, err := sr.mergeFiles(names)
+		if name != "" {
+			merged = append(merged, name)
+		}
+		if err != nil {
+			// Keep the names of the files that remain for Close.
+			sr.files = append(merged, sr.files[i:]...)
+			return err
+		}
+	}
+	sr.files = merged
+	return nil
+}
+
+// mergeFiles merges the files into a new temporary file and removes them.
+func (sr *SortReader) mergeFiles(names []string) (string, error) {
+	m, err := sr.openMerge(names)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp("", "tbln-sort-")
+	if err != nil {
+		return "", err
+	}
+	bw := bufio.NewWriter(f)
+	w := NewWriter(bw)
+	for {
+		row, err := m.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			_ = f.Close()
+			return f.Name(), err
+		}
+		if err := w.WriteRow(row); err != nil {
+			_ = f.Close()
+			return f.Name(), err
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		_ = f.Close()
+		return f.Name(), err
+	}
+	if err := f.Close(); err != nil {
+		return f.Name(), err
+	}
+	for _, o := range sr.open {
+		if err := o.Close(); err != nil {
+			return f.Name(), err
+		}
+	}
+	sr.open = nil
+	for _, name := range names {
+		if err := os.Remove(name); err != nil {
+			return f.Name(), err
+		}
+	}
+	return f.Name(), nil
+}
+
+func (sr *SortReader) sortRows() {
+	slices.SortStableFunc(sr.rows, func(a, b []string) int {
+		return compareKey(sr.pk, sr.coll, a, b)
+	})
+}
+
+// spill writes the sorted rows to a temporary file.
+func (sr *SortReader) spill() error {
+	if len(sr.rows) == 0 {
+		return nil
+	}
+	sr.sortRows()
+	f, err := os.CreateTemp("", "tbln-sort-")
+	if err != nil {
+		return err
+	}
+	sr.files = append(sr.files, f.Name())
+	bw := bufio.NewWriter(f)
+	w := NewWriter(bw)
+	for _, row := range sr.rows {
+		if err := w.WriteRow(row); err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+	sr.rows = sr.rows[:0]
+	if err := bw.Flush(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	// The file is opened again when it is merged.
+	return f.Close()
+}
+
+// sortItem is the current row of a spilled file.
+type sortItem struct {
+	row []string
+	src int
+	r   *FileReader
+}
+
+// sortMerge is a heap that merges the spilled files.
+type sortMerge struct {
+	pk    []Pkey
+	coll  collation
+	items []sortItem
+}
+
+// next returns the smallest row and reads the next row of its file.
+func (m *sortMerge) next() ([]string, error) {
+	if m.Len() == 0 {
+		return nil, io.EOF
+	}
+	item := heap.Pop(m).(sortItem)
+	next, err := item.r.ReadRow()
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if len(next) > 0 {
+		heap.Push(m, sortItem{row: next, src: item.src, r: item.r})
+	}
+	return item.row, nil
+}
+
+func (m *sortMerge) Len() int { return len(m.items) }
+
+func (m *sortMerge) Less(i, j int) bool {
+	c := compareKey(m.pk, m.coll, m.items[i].row, m.items[j].row)
+	if c == 0 {
+		// Keep the order of the input (stable).
+		return m.items[i].src < m.items[j].src
+	}
+	return c < 0
+}
+
+func (m *sortMerge) Swap(i, j int) { m.items[i], m.items[j] = m.items[j], m.items[i] }
+
+func (m *sortMerge) Push(x any) { m.items = append(m.items, x.(sortItem)) }
+
+func (m *sortMerge) Pop() any {
+	n := len(m.items)
+	item := m.items[n-1]
+	m.items = m.items[:n-1]
+	return item
+}
+
+// unreadReader returns the row that has already been read first.
+type unreadReader struct {
+	Reader
+	row []string
+	eof bool
+}
+
+func newUnreadReader(r Reader) (*unreadReader, error) {
+	row, err := r.ReadRow()
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return &unreadReader{Reader: r, row: row, eof: len(row) == 0}, nil
+}
+
+func (u *unreadReader) ReadRow() ([]string, error) {
+	if u.eof {
+		return nil, io.EOF
+	}
+	if u.row != nil {
+		row := u.row
+		u.row = nil
+		return row, nil
+	}
+	return u.Reader.ReadRow()
+}
+
+func (u *unreadReader) reusesRecord() bool {
+	return reusesRecord(u.Reader)
+}
@@ -0,0 +1,255 @@
+package tbln
+
+import (
+	"bytes"
+	"reflect"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+var TestUnsorted1 = `; name: | id | name | age |
+; type: | int | text | int |
+; primarykey: | id |
+; TableName: test1
+| 3 | Henry | 19 |
+| 10 | Carol | 30 |
+| 1 | Bob | 19 |
+| 2 | Alice | 14 |
+`
+
+var TestUnsorted2 = `; name: | id | name | age |
+; type: | int | text | int |
+; primarykey: | id |
+; TableName: test1
+| 10 | Carol | 30 |
+| 2 | Alice | 15 |
+| 3 | Henry | 19 |
+`
+
+func TestSortReader(t *testing.T) {
+	want := [][]string{{"1", "Bob", "19"}, {"2", "Alice", "14"}, {"3", "Henry", "19"}, {"10", "Carol", "30"}}
+	for _, maxRows := range []int{0, 1, 2, 3, 100} {
+		sr := NewSortReader(NewReader(bytes.NewBufferString(TestUnsorted1)))
+		sr.MaxRows = maxRows
+		got := readRowsHelper(t, sr)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("SortReader.ReadRow() MaxRows %d = %v, want %v", maxRows, got, want)
+		}
+		if len(sr.files) != 0 {
+			t.Errorf("SortReader temporary files are not removed")
+		}
+		if sr.GetDefinition().TableName() != "test1" {
+			t.Errorf("SortReader.GetDefinition() = %v", sr.GetDefinition())
+		}
+	}
+}
+
+func TestCompare_Unsorted(t *testing.T) {
+	var buf bytes.Buffer
+	err := DiffAll(&buf, NewReader(bytes.NewBufferString(TestUnsorted1)), NewReader(bytes.NewBufferString(TestUnsorted2)), AllDiff)
+	if err == nil {
+		t.Errorf("DiffAll() unsorted error = nil, want error")
+	}
+
+	cmp, err := NewCompareUnsorted(NewReader(bytes.NewBufferString(TestUnsorted1)), NewReader(bytes.NewBufferString(TestUnsorted2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int{-1, 2, 0, 0}
+	for _, les := range want {
+		dd, err := cmp.ReadDiffRow()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dd.Les != les {
+			t.Errorf("Compare.ReadDiffRow() = %v, want Les %d", dd, les)
+		}
+	}
+
+	s1, s2, err := NewSortReaders(NewReader(bytes.NewBufferString(TestUnsorted1)), NewReader(bytes.NewBufferString(TestUnsorted2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if err := DiffAll(&buf, s1, s2, OnlyDiff); err != nil {
+		t.Fatalf("DiffAll() error = %v", err)
+	}
+	wantDiff := `-| 1 | Bob | 19 |
+-| 2 | Alice | 14 |
++| 2 | Alice | 15 |
+`
+	if buf.String() != wantDiff {
+		t.Errorf("DiffAll() = %v, want %v", buf.String(), wantDiff)
+	}
+}
+
+func TestUnsortedAll(t *testing.T) {
+	var buf bytes.Buffer
+	err := DiffAllUnsorted(&buf, NewReader(bytes.NewBufferString(TestUnsorted1)), NewReader(bytes.NewBufferString(TestUnsorted2)), OnlyDiff)
+	if err != nil {
+		t.Fatalf("DiffAllUnsorted() error = %v", err)
+	}
+	wantDiff := `-| 1 | Bob | 19 |
+-| 2 | Alice | 14 |
++| 2 | Alice | 15 |
+`
+	if buf.String() != wantDiff {
+		t.Errorf("DiffAllUnsorted() = %v, want %v", buf.String(), wantDiff)
+	}
+
+	tb, err := MergeAllUnsorted(NewReader(bytes.NewBufferString(TestUnsorted1)), NewReader(bytes.NewBufferString(TestUnsorted2)), MergeDelete)
+	if err != nil {
+		t.Fatalf("MergeAllUnsorted() error = %v", err)
+	}
+	want := [][]string{{"2", "Alice", "15"}, {"3", "Henry", "19"}, {"10", "Carol", "30"}}
+	if !reflect.DeepEqual(tb.Rows, want) {
+		t.Errorf("MergeAllUnsorted() = %v, want %v", tb.Rows, want)
+	}
+
+	tb, err = ExceptAllUnsorted(NewReader(bytes.NewBufferString(TestUnsorted1)), NewReader(bytes.NewBufferString(TestUnsorted2)))
+	if err != nil {
+		t.Fatalf("ExceptAllUnsorted() error = %v", err)
+	}
+	want = [][]string{{"1", "Bob", "19"}, {"2", "Alice", "14"}}
+	if !reflect.DeepEqual(tb.Rows, want) {
+		t.Errorf("ExceptAllUnsorted() = %v, want %v", tb.Rows, want)
+	}
+}
+
+func TestCompare_Close(t *testing.T) {
+	s1, s2, err := NewSortReaders(NewReader(bytes.NewBufferString(TestUnsorted1)), NewReader(bytes.NewBufferString(TestUnsorted2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s1.MaxRows, s2.MaxRows = 1, 1
+	cmp, err := NewCompare(s1, s2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s1.files) == 0 || len(s2.files) == 0 {
+		t.Fatalf("SortReader temporary files are not created")
+	}
+	if _, err := cmp.ReadDiffRow(); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmp.Close(); err != nil {
+		t.Fatalf("Compare.Close() error = %v", err)
+	}
+	if len(s1.files) != 0 || len(s2.files) != 0 {
+		t.Errorf("Compare.Close() temporary files are not removed")
+	}
+}
+
+func TestCompareUnsorted_NoPK(t *testing.T) {
+	src1 := `; name: | x | y |
+; type: | int | int |
+| 2 | 1 |
+| 1 | 2 |
+`
+	src2 := `; name: | y | x |
+; type: | int | int |
+| 1 | 2 |
+| 2 | 1 |
+`
+	cmp, err := NewCompareUnsorted(NewReader(bytes.NewBufferString(src1)), NewReader(bytes.NewBufferString(src2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		dd, err := cmp.ReadDiffRow()
+		if err != nil {
+			t.Fatalf("Compare.ReadDiffRow() error = %v", err)
+		}
+		if dd.Les != 0 {
+			t.Errorf("Compare.ReadDiffRow() = %v, want Les 0", dd)
+		}
+	}
+}
+
+func TestSortReader_Columns(t *testing.T) {
+	sr := NewSortReader(NewReader(bytes.NewBufferString(TestUnsorted1)), "name")
+	got := readRowsHelper(t, sr)
+	want := [][]string{{"2", "Alice", "14"}, {"1", "Bob", "19"}, {"10", "Carol", "30"}, {"3", "Henry", "19"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SortReader.ReadRow() = %v, want %v", got, want)
+	}
+	sr = NewSortReader(NewReader(bytes.NewBufferString(TestUnsorted1)), "none")
+	if _, err := sr.ReadRow(); err == nil {
+		t.Errorf("SortReader.ReadRow() no column error = nil, want error")
+	}
+}
+
+func TestSortAll(t *testing.T) {
+	tb, err := ReadAll(bytes.NewBufferString(TestUnsorted1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tb.SumHash(SHA256); err != nil {
+		t.Fatal(err)
+	}
+	var src bytes.Buffer
+	if err := WriteAll(&src, tb); err != nil {
+		t.Fatal(err)
+	}
+	sr := NewSortReader(NewReader(&src))
+	sr.MaxRows = 2
+	var buf bytes.Buffer
+	if err := SortAll(&buf, sr); err != nil {
+		t.Fatalf("SortAll() error = %v", err)
+	}
+	got, err := ReadAll(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"1", "Bob", "19"}, {"2", "Alice", "14"}, {"3", "Henry", "19"}, {"10", "Carol", "30"}}
+	if !reflect.DeepEqual(got.Rows, want) {
+		t.Errorf("SortAll() = %v, want %v", got.Rows, want)
+	}
+	if got.TableName() != "test1" {
+		t.Errorf("SortAll() TableName = %v, want test1", got.TableName())
+	}
+	if !got.Verify() {
+		t.Errorf("SortAll() hash is not recomputed")
+	}
+}
+
+func TestSortReader_MaxFiles(t *testing.T) {
+	tb := NewTBLN()
+	if err := tb.SetNames([]string{"id", "seq"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tb.SetTypes([]string{"int", "int"}); err != nil {
+		t.Fatal(err)
+	}
+	for i := range 50 {
+		if err := tb.AddRows([]string{strconv.Itoa((i * 7) % 10), strconv.Itoa(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := slices.Clone(tb.Rows)
+	slices.SortStableFunc(want, func(a, b []string) int {
+		x, _ := strconv.Atoi(a[0])
+		y, _ := strconv.Atoi(b[0])
+		return x - y
+	})
+	for _, maxFiles := range []int{0, 2, 3, 64} {
+		sr := NewSortReader(NewOwnReader(tb), "id")
+		sr.MaxRows = 3
+		sr.MaxFiles = maxFiles
+		row, err := sr.ReadRow()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if maxFiles > 1 && (len(sr.files) > maxFiles || len(sr.open) > maxFiles) {
+			t.Errorf("SortReader MaxFiles %d files = %d, open = %d", maxFiles, len(sr.files), len(sr.open))
+		}
+		got := append([][]string{row}, readRowsHelper(t, sr)...)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("SortReader.ReadRow() MaxFiles %d = %v, want %v", maxFiles, got, want)
+		}
+		if len(sr.files) != 0 || len(sr.open) != 0 {
+			t.Errorf("SortReader temporary files are not removed")
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package tbln
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DiffStats represents the summary of the differences.
+// The sample keys are the primary keys of the first rows of each kind.
+type DiffStats struct {
+	Equal        int           `json:"equal"`
+	Added        int           `json:"added"`
+	Deleted      int           `json:"deleted"`
+	Modified     int           `json:"modified"`
+	Columns      []ColumnCount `json:"columns,omitempty"`
+	AddedKeys    [][]string    `json:"added_keys,omitempty"`
+	DeletedKeys  [][]string    `json:"deleted_keys,omitempty"`
+	ModifiedKeys [][]string    `json:"modified_keys,omitempty"`
+}
+
+// ColumnCount is the number of the modified rows of a column.
+type ColumnCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// DiffStats reads the remaining rows and returns the summary of the differences.
+// samples is the maximum number of the sample keys of each kind.
+func (cmp *Compare) DiffStats(samples int) (*DiffStats, error) {
+	st := &DiffStats{}
+	var counts []int
+	for {
+		dd, err := cmp.ReadDiffRow()
+		if err != nil {
+			if err != io.EOF {
+				return nil, err
+			}
+			break
+		}
+		switch dd.Les {
+		case 0:
+			st.Equal++
+		case 1:
+			st.Added++
+			st.AddedKeys = cmp.sampleKey(st.AddedKeys, samples, dd.Other)
+		case -1:
+			st.Deleted++
+			st.DeletedKeys = cmp.sampleKey(st.DeletedKeys, samples, dd.Self)
+		case 2:
+			st.Modified++
+			st.ModifiedKeys = cmp.sampleKey(st.ModifiedKeys, samples, dd.Self)
+			for _, p := range dd.Changed {
+				if p >= len(counts) {
+					counts = append(counts, make([]int, p-len(counts)+1)...)
+				}
+				counts[p]++
+			}
+		}
+	}
+	for p, c := range counts {
+		if c > 0 {
+			st.Columns = append(st.Columns, ColumnCount{Name: cmp.columnName(p), Count: c})
+		}
+	}
+	return st, nil
+}
+
+func (cmp *Compare) sampleKey(keys [][]string, samples int, row []string) [][]string {
+	if len(keys) >= samples {
+		return keys
+	}
+	return append(keys, ColumnPrimaryKey(cmp.PK, row))
+}
+
+// columnName returns the name of the column, or the position if there is no name.
+func (cmp *Compare) columnName(p int) string {
+	if p < len(cmp.Columns) {
+		return cmp.Columns[p]
+	}
+	return fmt.Sprintf("%d", p)
+}
+
+// String returns the summary as text.
+func (st *DiffStats) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "equal: %d\n", st.Equal)
+	fmt.Fprintf(&b, "added: %d\n", st.Added)
+	fmt.Fprintf(&b, "deleted: %d\n", st.Deleted)
+	fmt.Fprintf(&b, "modified: %d\n", st.Modified)
+	for _, c := range st.Columns {
+		fmt.Fprintf(&b, "  %s: %d\n", c.Name, c.Count)
+	}
+	samples := []struct {
+		name string
+		keys [][]string
+	}{
+		{"added keys", st.AddedKeys},
+		{"deleted keys", st.DeletedKeys},
+		{"modified keys", st.ModifiedKeys},
+	}
+	for _, s := range samples {
+		if len(s.keys) == 0 {
+			continue
+		}
+		keys := make([]string, 0, len(s.keys))
+		for _, k := range s.keys {
+			keys = append(keys, JoinRow(k))
+		}
+		fmt.Fprintf(&b, "%s: %s\n", s.name, strings.Join(keys, " "))
+	}
+	return b.String()
+}
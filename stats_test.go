@@ -0,0 +1,55 @@
+package tbln
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestCompare_DiffStats(t *testing.T) {
+	cmp, err := NewCompare(NewReader(bytes.NewBufferString(TestUnified1)), NewReader(bytes.NewBufferString(TestUnified2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err := cmp.DiffStats(1)
+	if err != nil {
+		t.Fatalf("Compare.DiffStats() error = %v", err)
+	}
+	want := `equal: 5
+added: 1
+deleted: 1
+modified: 1
+  age: 1
+added keys: | 8 |
+deleted keys: | 7 |
+modified keys: | 2 |
+`
+	if got := st.String(); got != want {
+		t.Errorf("DiffStats.String() = \n%v, want \n%v", got, want)
+	}
+	b, err := json.Marshal(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantJSON := `{"equal":5,"added":1,"deleted":1,"modified":1,"columns":[{"name":"age","count":1}],"added_keys":[["8"]],"deleted_keys":[["7"]],"modified_keys":[["2"]]}`
+	if string(b) != wantJSON {
+		t.Errorf("DiffStats JSON = %s, want %s", b, wantJSON)
+	}
+}
+
+func TestCompare_DiffStatsSamples(t *testing.T) {
+	cmp, err := NewCompare(NewReader(bytes.NewBufferString(TestSet1)), NewReader(bytes.NewBufferString(TestDiff1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err := cmp.DiffStats(0)
+	if err != nil {
+		t.Fatalf("Compare.DiffStats() error = %v", err)
+	}
+	if st.AddedKeys != nil || st.DeletedKeys != nil || st.ModifiedKeys != nil {
+		t.Errorf("DiffStats() samples = %v", st)
+	}
+	if st.Equal != 1 || st.Deleted != 2 || st.Added != 0 || st.Modified != 0 {
+		t.Errorf("DiffStats() = %v", st)
+	}
+}
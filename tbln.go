@@ -58,27 +58,20 @@ func NewTBLN() *TBLN {
 
 // JoinRow makes a Row array a character string.
 func JoinRow(row []string) string {
-	var b strings.Builder
 	if len(row) == 0 {
 		return ""
 	}
-	_, err := b.WriteString("|")
-	if err != nil {
-		return ""
+	n := 1
+	for _, column := range row {
+		n += len(column) + 3
 	}
+	var b strings.Builder
+	b.Grow(n)
+	b.WriteByte('|')
 	for _, column := range row {
-		_, err = b.WriteString(" ")
-		if err != nil {
-			return ""
-		}
-		_, err = b.WriteString(escape(column))
-		if err != nil {
-			return ""
-		}
-		_, err = b.WriteString(" |")
-		if err != nil {
-			return ""
-		}
+		b.WriteByte(' ')
+		writeEscape(&b, column)
+		b.WriteString(" |")
 	}
 	return b.String()
 }
@@ -87,14 +80,42 @@ func JoinRow(row []string) string {
 var ESCAPE = regexp.MustCompile(`(\|+)`)
 
 func escape(str string) string {
-	if strings.Contains(str, "|") {
-		str = ESCAPE.ReplaceAllString(str, "|$1")
+	if strings.IndexByte(str, '|') < 0 {
+		return str
+	}
+	var b strings.Builder
+	b.Grow(len(str) + 2)
+	writeEscape(&b, str)
+	return b.String()
+}
+
+// writeEscape writes str to b with one more vertical bar
+// for each sequence of vertical bars.
+func writeEscape(b *strings.Builder, str string) {
+	for {
+		i := strings.IndexByte(str, '|')
+		if i < 0 {
+			b.WriteString(str)
+			return
+		}
+		b.WriteString(str[:i+1])
+		b.WriteByte('|')
+		str = str[i+1:]
+		for len(str) > 0 && str[0] == '|' {
+			b.WriteByte('|')
+			str = str[1:]
+		}
 	}
-	return str
 }
 
 // SplitRow divides a character string into a row array.
 func SplitRow(str string) []string {
+	return splitRow(nil, str)
+}
+
+// splitRow appends the columns of str to rec.
+// If rec is nil, a new slice is allocated.
+func splitRow(rec []string, str string) []string {
 	if len(str) < 4 {
 		return nil
 	}
@@ -104,11 +125,18 @@ func SplitRow(str string) []string {
 	if str[len(str)-2:] == " |" {
 		str = str[0 : len(str)-2]
 	}
-	rec := strings.Split(str, " | ")
-	for i, column := range rec {
-		rec[i] = unescape(column)
+	if rec == nil {
+		rec = make([]string, 0, strings.Count(str, " | ")+1)
+	}
+	for {
+		i := strings.Index(str, " | ")
+		if i < 0 {
+			break
+		}
+		rec = append(rec, unescape(str[:i]))
+		str = str[i+3:]
 	}
-	return rec
+	return append(rec, unescape(str))
 }
 
 // UNESCAPE is unescape || -> |
@@ -116,10 +144,26 @@ var UNESCAPE = regexp.MustCompile(`\|(\|+)`)
 
 // unescape vertical bars || -> |
 func unescape(str string) string {
-	if strings.Contains(str, "|") {
-		str = UNESCAPE.ReplaceAllString(str, "$1")
+	i := strings.Index(str, "||")
+	if i < 0 {
+		return str
 	}
-	return str
+	var b strings.Builder
+	b.Grow(len(str) - 1)
+	for i >= 0 {
+		// Remove one vertical bar from the sequence.
+		b.WriteString(str[:i])
+		str = str[i+1:]
+		j := 0
+		for j < len(str) && str[j] == '|' {
+			j++
+		}
+		b.WriteString(str[:j])
+		str = str[j:]
+		i = strings.Index(str, "||")
+	}
+	b.WriteString(str)
+	return b.String()
 }
 
 // AddRows is Add row to Table.
@@ -150,6 +194,18 @@ const (
 	SHA512 = "sha512" // import crypto/sha512
 )
 
+// newHash returns hash.Hash of hashType.
+func newHash(hashType string) (hash.Hash, error) {
+	switch hashType {
+	case SHA256:
+		return sha256.New(), nil
+	case SHA512:
+		return sha512.New(), nil
+	default:
+		return nil, fmt.Errorf("not support")
+	}
+}
+
 // SumHash calculated checksum.
 func (t *TBLN) SumHash(hashType string) error {
 	h, err := t.calculateHash(hashType)
@@ -166,14 +222,9 @@ func (t *TBLN) SumHash(hashType string) error {
 
 // calculateHash is returns the calculated checksum.
 func (t *TBLN) calculateHash(hashType string) ([]byte, error) {
-	var hash hash.Hash
-	switch hashType {
-	case SHA256:
-		hash = sha256.New()
-	case SHA512:
-		hash = sha512.New()
-	default:
-		return nil, fmt.Errorf("not support")
+	hash, err := newHash(hashType)
+	if err != nil {
+		return nil, err
 	}
 	w := NewWriter(hash)
 	if err := w.writeExtraTarget(t.Definition, true); err != nil {
@@ -207,15 +258,8 @@ func (t *TBLN) VerifySignature(name string, pubkey []byte) bool {
 	if t == nil || t.Definition == nil || len(t.Signs) == 0 {
 		return false
 	}
-	if len(pubkey) != ed25519.PublicKeySize {
-		return false
-	}
-	s := t.Signs[name]
-	if s.algorithm == ED25519 {
-		x := ed25519.PublicKey(pubkey)
-		if ed25519.Verify(x, t.SerializeHash(), s.sign) {
-			return t.Verify()
-		}
+	if verifySign(t.Signs[name], pubkey, t.SerializeHash()) {
+		return t.Verify()
 	}
 	return false
 }
@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -409,3 +410,105 @@ func TestTBLN_Verify(t *testing.T) {
 		})
 	}
 }
+
+// splitRowRegexp is the previous implementation of SplitRow with regexp.
+func splitRowRegexp(str string) []string {
+	if len(str) < 4 {
+		return nil
+	}
+	if str[:2] == "| " {
+		str = str[2:]
+	}
+	if str[len(str)-2:] == " |" {
+		str = str[0 : len(str)-2]
+	}
+	rec := strings.Split(str, " | ")
+	for i, column := range rec {
+		if strings.Contains(column, "|") {
+			rec[i] = UNESCAPE.ReplaceAllString(column, "$1")
+		}
+	}
+	return rec
+}
+
+// joinRowRegexp is the previous implementation of JoinRow with regexp.
+func joinRowRegexp(row []string) string {
+	if len(row) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("|")
+	for _, column := range row {
+		if strings.Contains(column, "|") {
+			column = ESCAPE.ReplaceAllString(column, "|$1")
+		}
+		b.WriteString(" " + column + " |")
+	}
+	return b.String()
+}
+
+func TestRow_compatible(t *testing.T) {
+	// All strings of up to 6 characters from the alphabet.
+	alphabet := []string{"|", " ", "a"}
+	strs := []string{""}
+	for i := 0; i < len(strs); i++ {
+		if len(strs[i]) < 6 {
+			for _, c := range alphabet {
+				strs = append(strs, strs[i]+c)
+			}
+		}
+	}
+	tr := NewReader(strings.NewReader(""))
+	tr.ReuseRecord = true
+	for _, str := range strs {
+		if got, want := SplitRow(str), splitRowRegexp(str); !reflect.DeepEqual(got, want) {
+			t.Errorf("SplitRow(%q) = %q, want %q", str, got, want)
+		}
+		if got, want := escape(str), ESCAPE.ReplaceAllString(str, "|$1"); got != want {
+			t.Errorf("escape(%q) = %q, want %q", str, got, want)
+		}
+		if got, want := unescape(str), UNESCAPE.ReplaceAllString(str, "$1"); got != want {
+			t.Errorf("unescape(%q) = %q, want %q", str, got, want)
+		}
+		row := []string{str, "a", str}
+		if got, want := JoinRow(row), joinRowRegexp(row); got != want {
+			t.Errorf("JoinRow(%q) = %q, want %q", row, got, want)
+		}
+		line := "| " + str
+		if got, want := tr.splitLine([]byte(line)), splitRowRegexp(line); !reflect.DeepEqual(got, want) {
+			t.Errorf("FileReader.splitLine(%q) = %q, want %q", line, got, want)
+		}
+	}
+}
+
+var benchRow = []string{"12345", "Bob | Alice", "2019-04-01T00:00:00+09:00", "a long text column with some words", "3.14"}
+
+func BenchmarkSplitRow(b *testing.B) {
+	str := JoinRow(benchRow)
+	b.ReportAllocs()
+	for b.Loop() {
+		SplitRow(str)
+	}
+}
+
+func BenchmarkSplitRowRegexp(b *testing.B) {
+	str := JoinRow(benchRow)
+	b.ReportAllocs()
+	for b.Loop() {
+		splitRowRegexp(str)
+	}
+}
+
+func BenchmarkJoinRow(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		JoinRow(benchRow)
+	}
+}
+
+func BenchmarkJoinRowRegexp(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		joinRowRegexp(benchRow)
+	}
+}
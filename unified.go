@@ -0,0 +1,257 @@
+package tbln
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// UnifiedDiff writes the difference between two readers in the unified diff format.
+// The header names both tables and their hashes. The lines are numbered
+// as the tables written by WriteAll, so the changes of the Definition are
+// hunks over the comment and extra lines, and the rows follow them.
+// context is the number of unchanged lines written around each change.
+func UnifiedDiff(writer io.Writer, t1, t2 Reader, context int) error {
+	cmp, err := NewCompare(t1, t2)
+	if err != nil {
+		return err
+	}
+	return cmp.WriteUnified(writer, context)
+}
+
+// WriteUnified writes the difference of the remaining rows in the unified
+// diff format as UnifiedDiff does, with the options set to cmp.
+func (cmp *Compare) WriteUnified(writer io.Writer, context int) error {
+	u := &unified{w: writer, context: max(context, 0)}
+	if err := u.writeHeader(cmp.t1.GetDefinition(), cmp.t2.GetDefinition()); err != nil {
+		return err
+	}
+	for {
+		dd, err := cmp.ReadDiffRow()
+		if err != nil {
+			if err == io.EOF {
+				return u.flush()
+			}
+			return err
+		}
+		switch dd.Les {
+		case 0:
+			err = u.equal(JoinRow(dd.Self))
+		case 1:
+			err = u.change("", JoinRow(dd.Other))
+		case -1:
+			err = u.change(JoinRow(dd.Self), "")
+		case 2:
+			err = u.change(JoinRow(dd.Self), JoinRow(dd.Other))
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// unified builds hunks from a stream of rows.
+type unified struct {
+	w       io.Writer
+	context int
+	hunk    *unifiedHunk
+	pre     []unifiedLine
+	gap     int
+	l1      int
+	l2      int
+}
+
+type unifiedHunk struct {
+	lines  []string
+	start1 int
+	start2 int
+	n1     int
+	n2     int
+}
+
+type unifiedLine struct {
+	text string
+	l1   int
+	l2   int
+}
+
+// writeHeader writes the file header and adds the lines of the Definitions
+// as written by WriteDefinition, so that the rows are numbered as file lines.
+func (u *unified) writeHeader(d1, d2 *Definition) error {
+	if _, err := fmt.Fprintf(u.w, "--- %s\n", unifiedTitle(d1, "a")); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(u.w, "+++ %s\n", unifiedTitle(d2, "b")); err != nil {
+		return err
+	}
+	lines1, err := definitionLines(d1)
+	if err != nil {
+		return err
+	}
+	lines2, err := definitionLines(d2)
+	if err != nil {
+		return err
+	}
+	// lcs[i][j] is the length of the longest common lines of lines1[i:] and lines2[j:].
+	lcs := make([][]int, len(lines1)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(lines2)+1)
+	}
+	for i := len(lines1) - 1; i >= 0; i-- {
+		for j := len(lines2) - 1; j >= 0; j-- {
+			if lines1[i] == lines2[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(lines1) || j < len(lines2) {
+		switch {
+		case i < len(lines1) && j < len(lines2) && lines1[i] == lines2[j]:
+			err = u.equal(lines1[i])
+			i++
+			j++
+		case j == len(lines2) || (i < len(lines1) && lcs[i+1][j] >= lcs[i][j+1]):
+			err = u.change(lines1[i], "")
+			i++
+		default:
+			err = u.change("", lines2[j])
+			j++
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// definitionLines returns the lines of d written by WriteDefinition.
+func definitionLines(d *Definition) ([]string, error) {
+	var buf bytes.Buffer
+	if err := NewWriter(&buf).WriteDefinition(d); err != nil {
+		return nil, err
+	}
+	if buf.Len() == 0 {
+		return nil, nil
+	}
+	return strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n"), nil
+}
+
+// unifiedTitle returns the table name and the hashes.
+func unifiedTitle(d *Definition, name string) string {
+	if d.TableName() != "" {
+		name = d.TableName()
+	}
+	hashes := make([]string, 0, len(d.Hashes))
+	for k, v := range d.Hashes {
+		hashes = append(hashes, fmt.Sprintf("%s:%x", k, v))
+	}
+	sort.Strings(hashes)
+	if len(hashes) == 0 {
+		return name
+	}
+	return name + "\t" + strings.Join(hashes, " ")
+}
+
+func unifiedExtras(d *Definition) map[string]string {
+	extras := make(map[string]string, len(d.Extras))
+	for k, v := range d.Extras {
+		extras[k] = fmt.Sprintf("%s", v.Value())
+	}
+	return extras
+}
+
+// unionKeys returns the sorted keys of both maps.
+func unionKeys(m1, m2 map[string]string) []string {
+	keys := make([]string, 0, len(m1)+len(m2))
+	for k := range m1 {
+		keys = append(keys, k)
+	}
+	for k := range m2 {
+		if _, ok := m1[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// equal adds an unchanged row.
+func (u *unified) equal(row string) error {
+	u.l1++
+	u.l2++
+	u.gap++
+	if u.hunk != nil && u.gap <= u.context {
+		u.hunk.lines = append(u.hunk.lines, " "+row)
+		u.hunk.n1++
+		u.hunk.n2++
+		return nil
+	}
+	u.pre = append(u.pre, unifiedLine{text: row, l1: u.l1, l2: u.l2})
+	if len(u.pre) > u.context {
+		u.pre = u.pre[1:]
+	}
+	// The next change is too far to be in the same hunk.
+	if u.hunk != nil && u.gap > u.context*2 {
+		return u.flush()
+	}
+	return nil
+}
+
+// change adds a deleted row and/or an added row.
+func (u *unified) change(del string, add string) error {
+	if u.hunk == nil {
+		u.hunk = &unifiedHunk{start1: u.l1 + 1, start2: u.l2 + 1}
+		if len(u.pre) > 0 {
+			u.hunk.start1 = u.pre[0].l1
+			u.hunk.start2 = u.pre[0].l2
+		}
+	}
+	for _, p := range u.pre {
+		u.hunk.lines = append(u.hunk.lines, " "+p.text)
+		u.hunk.n1++
+		u.hunk.n2++
+	}
+	u.pre = u.pre[:0]
+	u.gap = 0
+	if del != "" {
+		u.l1++
+		u.hunk.lines = append(u.hunk.lines, "-"+del)
+		u.hunk.n1++
+	}
+	if add != "" {
+		u.l2++
+		u.hunk.lines = append(u.hunk.lines, "+"+add)
+		u.hunk.n2++
+	}
+	return nil
+}
+
+// flush writes the current hunk.
+func (u *unified) flush() error {
+	h := u.hunk
+	if h == nil {
+		return nil
+	}
+	u.hunk = nil
+	start1, start2 := h.start1, h.start2
+	if h.n1 == 0 {
+		start1--
+	}
+	if h.n2 == 0 {
+		start2--
+	}
+	if _, err := fmt.Fprintf(u.w, "@@ -%d,%d +%d,%d @@\n", start1, h.n1, start2, h.n2); err != nil {
+		return err
+	}
+	for _, line := range h.lines {
+		if _, err := fmt.Fprintf(u.w, "%s\n", line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package tbln
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+var TestUnified1 = `; name: | id | name | age |
+; type: | int | text | int |
+; primarykey: | id |
+; TableName: test1
+| 1 | Bob | 19 |
+| 2 | Alice | 14 |
+| 3 | Henry | 19 |
+| 4 | Carol | 30 |
+| 5 | Dave | 41 |
+| 6 | Eve | 22 |
+| 7 | Frank | 33 |
+`
+
+var TestUnified2 = `; name: | id | name | age |
+; type: | int | text | int |
+; primarykey: | id |
+; TableName: test2
+| 1 | Bob | 19 |
+| 2 | Alice | 15 |
+| 3 | Henry | 19 |
+| 4 | Carol | 30 |
+| 5 | Dave | 41 |
+| 6 | Eve | 22 |
+| 8 | Grace | 27 |
+`
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name    string
+		t1      string
+		t2      string
+		context int
+		want    string
+	}{
+		{
+			name:    "context1",
+			t1:      TestUnified1,
+			t2:      TestUnified2,
+			context: 1,
+			want: `--- test1
++++ test2
+@@ -1,2 +1,2 @@
+-; TableName: test1
++; TableName: test2
+ ; name: | id | name | age |
+@@ -5,3 +5,3 @@
+ | 1 | Bob | 19 |
+-| 2 | Alice | 14 |
++| 2 | Alice | 15 |
+ | 3 | Henry | 19 |
+@@ -10,2 +10,2 @@
+ | 6 | Eve | 22 |
+-| 7 | Frank | 33 |
++| 8 | Grace | 27 |
+`,
+		},
+		{
+			name:    "context2",
+			t1:      TestUnified1,
+			t2:      TestUnified2,
+			context: 2,
+			want: `--- test1
++++ test2
+@@ -1,11 +1,11 @@
+-; TableName: test1
++; TableName: test2
+ ; name: | id | name | age |
+ ; primarykey: | id |
+ ; type: | int | text | int |
+ | 1 | Bob | 19 |
+-| 2 | Alice | 14 |
++| 2 | Alice | 15 |
+ | 3 | Henry | 19 |
+ | 4 | Carol | 30 |
+ | 5 | Dave | 41 |
+ | 6 | Eve | 22 |
+-| 7 | Frank | 33 |
++| 8 | Grace | 27 |
+`,
+		},
+		{
+			name:    "insert",
+			t1:      TestDiff1,
+			t2:      TestDiff2,
+			context: 0,
+			want: `--- test1
++++ test1
+@@ -5,0 +6,1 @@
++| 2 | Alice | 14 |
+`,
+		},
+		{
+			name:    "same",
+			t1:      TestDiff1,
+			t2:      TestDiff1,
+			context: 3,
+			want: `--- test1
++++ test1
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer := &bytes.Buffer{}
+			err := UnifiedDiff(writer, NewReader(bytes.NewBufferString(tt.t1)), NewReader(bytes.NewBufferString(tt.t2)), tt.context)
+			if err != nil {
+				t.Fatalf("UnifiedDiff() error = %v", err)
+			}
+			if got := writer.String(); got != tt.want {
+				t.Errorf("UnifiedDiff() = \n%v, want \n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnifiedDiffHash(t *testing.T) {
+	tb, err := ReadAll(bytes.NewBufferString(TestDiff1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tb.SumHash(SHA256); err != nil {
+		t.Fatal(err)
+	}
+	writer := &bytes.Buffer{}
+	if err := UnifiedDiff(writer, NewOwnReader(tb), NewOwnReader(tb), 0); err != nil {
+		t.Fatal(err)
+	}
+	h := fmt.Sprintf("%x", tb.Hashes[SHA256])
+	want := "--- test1\tsha256:" + h + "\n+++ test1\tsha256:" + h + "\n"
+	if got := writer.String(); got != want {
+		t.Errorf("UnifiedDiff() = %v, want %v", got, want)
+	}
+}
@@ -39,6 +39,30 @@ func WriteAll(writer io.Writer, tbln *TBLN) error {
 	return nil
 }
 
+// WriteReader writes the definition and all records of r to w.
+// The Definition is written after reading the first record,
+// because the Definition of Reader may not be complete before that.
+func WriteReader(writer io.Writer, r Reader) error {
+	row, err := r.ReadRow()
+	if err != nil && err != io.EOF {
+		return err
+	}
+	w := NewWriter(writer)
+	if err := w.WriteDefinition(r.GetDefinition()); err != nil {
+		return err
+	}
+	for len(row) > 0 {
+		if err := w.WriteRow(row); err != nil {
+			return err
+		}
+		row, err = r.ReadRow()
+		if err != nil && err != io.EOF {
+			return err
+		}
+	}
+	return nil
+}
+
 // WriteDefinition writes Definition (comment and extra) to w.
 func (w *Writer) WriteDefinition(d *Definition) error {
 	if err := w.writeComment(d); err != nil {
@@ -332,3 +332,23 @@ func TestWriter_writeSigns(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteReader(t *testing.T) {
+	src := `; name: | id | name |
+; type: | int | text |
+| 1 | Bob |
+| 2 | Alice |
+`
+	var buf bytes.Buffer
+	if err := WriteReader(&buf, NewReader(bytes.NewBufferString(src))); err != nil {
+		t.Fatalf("WriteReader() error = %v", err)
+	}
+	want := `; name: | id | name |
+; type: | int | text |
+| 1 | Bob |
+| 2 | Alice |
+`
+	if got := buf.String(); got != want {
+		t.Errorf("WriteReader() = \n%v, want \n%v", got, want)
+	}
+}